@@ -0,0 +1,193 @@
+package blob
+
+import (
+	"math"
+	"unsafe"
+
+	"github.com/iv-menshenin/fusion/collection"
+	"github.com/iv-menshenin/fusion/errors"
+)
+
+// Store keeps variable-length records packed into large byte slabs instead of allocating each one separately.
+//
+// Every record gets an integer ID which stays valid until the record is deleted, even after Compact. The records are
+// addressed through an offset index which is a Collection, so the index does not reallocate when it grows either.
+//
+// The slabs are never overwritten: deleting marks the record with a tombstone, and Compact moves the live records
+// to new slabs. That is why the data returned by Get and GetString is not copied and stays intact even after
+// the record is deleted. Do not modify the returned slices.
+type Store struct {
+	slabSz  int
+	slabs   [][]byte
+	cur     int
+	index   *collection.Collection[record]
+	live    int
+	garbage int
+}
+
+type record struct {
+	slab int32
+	off  uint32
+	len  int32
+}
+
+const (
+	defaultSlabSz = 1 << 20
+	tombstone     = -1
+
+	// the offsets and the lengths in the index are 32-bit
+	maxSlabSz   = math.MaxInt32
+	maxRecordSz = math.MaxInt32
+)
+
+// New creates a new Store with the specified slab size. If the size is zero, the default value (1 MiB) will be used.
+// The slab size is limited to 2 GiB.
+//
+// Records larger than a quarter of the slab size get their own dedicated slabs.
+func New(slabSz int) *Store {
+	if slabSz <= 0 {
+		slabSz = defaultSlabSz
+	}
+	if slabSz > maxSlabSz {
+		slabSz = maxSlabSz
+	}
+	return &Store{
+		slabSz: slabSz,
+		cur:    -1,
+		index:  collection.New[record](0),
+	}
+}
+
+// Len returns the count of live records.
+func (s *Store) Len() int {
+	return s.live
+}
+
+// Garbage returns the count of bytes occupied by deleted records, which can be released with Compact.
+func (s *Store) Garbage() int {
+	return s.garbage
+}
+
+// Put copies the data into the Store and returns the ID of the new record.
+// It panics with errors.ErrTooLarge if the data is larger than 2 GiB.
+func (s *Store) Put(data []byte) int {
+	checkRecordSz(len(data))
+	id := s.index.Len()
+	s.index.Push(s.write(data))
+	s.live++
+	return id
+}
+
+// checkRecordSz panics with errors.ErrTooLarge if the record length does not fit the index.
+func checkRecordSz(n int) {
+	if n > maxRecordSz {
+		panic(errors.ErrTooLarge)
+	}
+}
+
+// PutString copies the string into the Store and returns the ID of the new record.
+func (s *Store) PutString(str string) int {
+	return s.Put(unsafeBytes(str))
+}
+
+func (s *Store) write(data []byte) record {
+	if len(data) > s.slabSz/4 {
+		// dedicated slab
+		slab := make([]byte, len(data))
+		copy(slab, data)
+		s.slabs = append(s.slabs, slab)
+		return record{slab: int32(len(s.slabs) - 1), len: int32(len(data))}
+	}
+	if s.cur < 0 || cap(s.slabs[s.cur])-len(s.slabs[s.cur]) < len(data) {
+		s.slabs = append(s.slabs, make([]byte, 0, s.slabSz))
+		s.cur = len(s.slabs) - 1
+	}
+	slab := s.slabs[s.cur]
+	r := record{slab: int32(s.cur), off: uint32(len(slab)), len: int32(len(data))}
+	s.slabs[s.cur] = append(slab, data...)
+	return r
+}
+
+// Get returns the data of the record without copying. It returns nil if the record does not exist or was deleted.
+func (s *Store) Get(id int) []byte {
+	r := s.record(id)
+	if r == nil {
+		return nil
+	}
+	end := int(r.off) + int(r.len)
+	return s.slabs[r.slab][r.off:end:end]
+}
+
+// GetString returns the data of the record as a string without copying.
+// It returns an empty string if the record does not exist or was deleted.
+func (s *Store) GetString(id int) string {
+	data := s.Get(id)
+	return *(*string)(unsafe.Pointer(&data))
+}
+
+// Has returns true if the record exists and was not deleted.
+func (s *Store) Has(id int) bool {
+	return s.record(id) != nil
+}
+
+func (s *Store) record(id int) *record {
+	if id < 0 {
+		return nil
+	}
+	r := s.index.Get(id)
+	if r == nil || r.len == tombstone {
+		return nil
+	}
+	return r
+}
+
+// Delete marks the record as deleted and returns true if it existed. The occupied space is released by Compact.
+func (s *Store) Delete(id int) bool {
+	r := s.record(id)
+	if r == nil {
+		return false
+	}
+	s.garbage += int(r.len)
+	r.len = tombstone
+	s.live--
+	return true
+}
+
+// Each iterates through all the live records in the order of their IDs and calls the provided callback function
+// for each of them. If the callback function returns false, the iteration will be stopped.
+func (s *Store) Each(callback func(id int, data []byte) bool) {
+	var id = -1
+	s.index.Each(func(r *record) bool {
+		id++
+		if r.len == tombstone {
+			return true
+		}
+		end := int(r.off) + int(r.len)
+		return callback(id, s.slabs[r.slab][r.off:end:end])
+	})
+}
+
+// Compact moves all the live records to new slabs and releases the old ones. The IDs of the records are kept.
+//
+// The data previously returned by Get remains valid, it is just no longer owned by the Store.
+func (s *Store) Compact() {
+	old := s.slabs
+	s.slabs = nil
+	s.cur = -1
+	s.index.Each(func(r *record) bool {
+		if r.len == tombstone {
+			return true
+		}
+		end := int(r.off) + int(r.len)
+		*r = s.write(old[r.slab][r.off:end])
+		return true
+	})
+	s.garbage = 0
+}
+
+func unsafeBytes(str string) []byte {
+	if str == "" {
+		return nil
+	}
+	return unsafe.Slice(*(**byte)(unsafe.Pointer(&str)), len(str))
+}
@@ -0,0 +1,126 @@
+package blob
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iv-menshenin/fusion/errors"
+)
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+	t.Run("put_get", func(t *testing.T) {
+		t.Parallel()
+
+		s := New(64)
+		foo := s.PutString("foo")
+		bar := s.Put([]byte("bar"))
+		empty := s.PutString("")
+		require.Equal(t, 3, s.Len())
+		require.Equal(t, "foo", s.GetString(foo))
+		require.Equal(t, []byte("bar"), s.Get(bar))
+		require.Equal(t, "", s.GetString(empty))
+		require.True(t, s.Has(empty))
+		require.Nil(t, s.Get(100))
+		require.Nil(t, s.Get(-1))
+	})
+	t.Run("slabs", func(t *testing.T) {
+		t.Parallel()
+
+		s := New(64)
+		var ids []int
+		for n := 0; n < 100; n++ {
+			ids = append(ids, s.PutString(strconv.Itoa(n*1000)))
+		}
+		large := s.PutString(strings.Repeat("x", 100))
+		for n, id := range ids {
+			require.Equal(t, strconv.Itoa(n*1000), s.GetString(id))
+		}
+		require.Equal(t, 100, len(s.GetString(large)))
+
+		// the returned slice must not overwrite the neighbour
+		data := s.Get(ids[0])
+		_ = append(data, '!')
+		require.Equal(t, "1000", s.GetString(ids[1]))
+	})
+	t.Run("delete_compact", func(t *testing.T) {
+		t.Parallel()
+
+		s := New(128)
+		var ids []int
+		for n := 0; n < 1000; n++ {
+			ids = append(ids, s.PutString("record-"+strconv.Itoa(n)))
+		}
+		kept := s.GetString(ids[1])
+		for n := 0; n < 1000; n += 2 {
+			require.True(t, s.Delete(ids[n]))
+		}
+		require.False(t, s.Delete(ids[0]))
+		require.Equal(t, 500, s.Len())
+		require.Greater(t, s.Garbage(), 0)
+		slabs := len(s.slabs)
+
+		s.Compact()
+		require.Equal(t, 0, s.Garbage())
+		require.Less(t, len(s.slabs), slabs)
+		require.Equal(t, "record-1", kept)
+		for n := 0; n < 1000; n++ {
+			if n%2 == 0 {
+				require.False(t, s.Has(ids[n]))
+				continue
+			}
+			require.Equal(t, "record-"+strconv.Itoa(n), s.GetString(ids[n]))
+		}
+
+		var count int
+		s.Each(func(id int, data []byte) bool {
+			require.Equal(t, 1, id%2)
+			count++
+			return true
+		})
+		require.Equal(t, 500, count)
+	})
+	t.Run("limits", func(t *testing.T) {
+		t.Parallel()
+		if math.MaxInt == math.MaxInt32 {
+			t.Skip("32-bit platform")
+		}
+
+		s := New(math.MaxInt)
+		require.Equal(t, maxSlabSz, s.slabSz)
+
+		size := maxRecordSz
+		require.NotPanics(t, func() {
+			checkRecordSz(size)
+		})
+		require.PanicsWithValue(t, errors.ErrTooLarge, func() {
+			checkRecordSz(size + 1)
+		})
+		require.Equal(t, 0, s.Len())
+	})
+}
+
+func BenchmarkStore(b *testing.B) {
+	b.Run("PutString", func(b *testing.B) {
+		b.ReportAllocs()
+		s := New(0)
+		for n := 0; n < b.N; n++ {
+			s.PutString("BenchmarkStore")
+		}
+	})
+	b.Run("GetString", func(b *testing.B) {
+		s := New(0)
+		for n := 0; n < 1_000_000; n++ {
+			s.PutString(strconv.Itoa(n))
+		}
+		b.ResetTimer()
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			_ = s.GetString(n % 1_000_000)
+		}
+	})
+}
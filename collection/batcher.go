@@ -0,0 +1,250 @@
+package collection
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/iv-menshenin/fusion/errors"
+)
+
+// Batcher accumulates pushed values into buckets and hands every full bucket to the sink function,
+// which is called on a background goroutine. The bucket size is the natural batch size.
+//
+// A bucket that is not full is also handed over once it gets older than the max age,
+// so the values do not stay in the Batcher for too long when the traffic is low.
+//
+// When the sink is slow and the count of buckets waiting for it reaches the max in-flight limit,
+// Push blocks until the sink catches up. This is the backpressure that keeps the memory bounded.
+//
+// The slice passed to the sink is reused after the sink returns, so the sink must not retain it.
+type Batcher[T any] struct {
+	bsz         int
+	maxAge      time.Duration
+	maxInFlight uint64
+	sink        func(batch []T)
+
+	mu      sync.Mutex
+	cur     *bucket[T]
+	count   int
+	started time.Time
+	closed  bool
+	pending []batch[T] // buckets handed over to the sink, in order
+	sent    uint64     // count of buckets ever handed over
+	ready   sync.Cond  // signals the worker about new pending buckets
+	free    chan *bucket[T]
+	stop    chan struct{}
+	stopped sync.WaitGroup
+
+	// progress of the sink
+	state    sync.Mutex
+	done     uint64
+	progress chan struct{}
+}
+
+type batch[T any] struct {
+	b     *bucket[T]
+	count int
+}
+
+// NewBatcher creates a new Batcher and starts its background goroutine. If the bucket size is zero,
+// the default value will be used. Zero max age disables flushing by age, and the max in-flight value
+// less than one means one bucket.
+//
+// Call Close to flush the remaining values and stop the background goroutine.
+func NewBatcher[T any](bucketSz int, maxAge time.Duration, maxInFlight int, sink func(batch []T)) *Batcher[T] {
+	if bucketSz == 0 {
+		bucketSz = defaultBucketSz
+	}
+	if maxInFlight < 1 {
+		maxInFlight = 1
+	}
+	b := Batcher[T]{
+		bsz:         bucketSz,
+		maxAge:      maxAge,
+		maxInFlight: uint64(maxInFlight),
+		sink:        sink,
+		free:        make(chan *bucket[T], maxInFlight+1),
+		stop:        make(chan struct{}),
+		progress:    make(chan struct{}),
+	}
+	b.ready.L = &b.mu
+	b.stopped.Add(1)
+	go b.work()
+	if maxAge > 0 {
+		b.stopped.Add(1)
+		go b.watch()
+	}
+	return &b
+}
+
+// Push adds a new value to the current bucket. If the bucket becomes full, it is handed over to the sink.
+//
+// Push panics if the Batcher is closed.
+func (b *Batcher[T]) Push(val T) {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		panic(errors.ErrClosed)
+	}
+	if b.cur == nil {
+		b.cur = b.newBucket()
+		b.started = time.Now()
+	}
+	b.cur.data[b.count] = val
+	b.count++
+	if b.count < b.bsz {
+		b.mu.Unlock()
+		return
+	}
+	b.handOver()
+	sent := b.sent
+	b.mu.Unlock()
+	// the backpressure is waited for without the lock, so other producers and Flush are not blocked by it
+	if sent > b.maxInFlight+1 {
+		_ = b.wait(context.Background(), sent-b.maxInFlight-1)
+	}
+}
+
+// Flush hands the current bucket over to the sink even if it is not full and waits until the sink processes
+// all the buckets pushed before the call, or until the context is done.
+func (b *Batcher[T]) Flush(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return errors.ErrClosed
+	}
+	b.handOver()
+	target := b.sent
+	b.mu.Unlock()
+	return b.wait(ctx, target)
+}
+
+// Close flushes the remaining values, waits until the sink processes them and stops the background goroutines.
+func (b *Batcher[T]) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return errors.ErrClosed
+	}
+	b.handOver()
+	b.closed = true
+	close(b.stop)
+	b.ready.Signal()
+	b.mu.Unlock()
+	b.stopped.Wait()
+	return nil
+}
+
+// handOver detaches the current bucket and queues it for the sink. It must be called under the lock
+// and never blocks: the callers wait for the backpressure after releasing the lock.
+func (b *Batcher[T]) handOver() {
+	if b.count == 0 {
+		return
+	}
+	b.pending = append(b.pending, batch[T]{b: b.cur, count: b.count})
+	b.sent++
+	b.cur = nil
+	b.count = 0
+	b.ready.Signal()
+}
+
+// next blocks until there is a pending bucket and takes it. It returns false when the Batcher is closed
+// and all the buckets are taken.
+func (b *Batcher[T]) next() (batch[T], bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for len(b.pending) == 0 {
+		if b.closed {
+			return batch[T]{}, false
+		}
+		b.ready.Wait()
+	}
+	job := b.pending[0]
+	n := copy(b.pending, b.pending[1:])
+	b.pending[n] = batch[T]{}
+	b.pending = b.pending[:n]
+	return job, true
+}
+
+func (b *Batcher[T]) newBucket() *bucket[T] {
+	select {
+	case reused := <-b.free:
+		return reused
+	default:
+		return &bucket[T]{data: make([]T, b.bsz)}
+	}
+}
+
+func (b *Batcher[T]) work() {
+	defer b.stopped.Done()
+	for {
+		job, ok := b.next()
+		if !ok {
+			return
+		}
+		b.sink(job.b.data[:job.count])
+		// clear cells
+		var empty T
+		for n := 0; n < job.count; n++ {
+			job.b.data[n] = empty
+		}
+		select {
+		case b.free <- job.b:
+		default:
+		}
+		b.state.Lock()
+		b.done++
+		close(b.progress)
+		b.progress = make(chan struct{})
+		b.state.Unlock()
+	}
+}
+
+func (b *Batcher[T]) watch() {
+	defer b.stopped.Done()
+	tick := b.maxAge / 4
+	if tick <= 0 {
+		tick = b.maxAge
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case now := <-ticker.C:
+			b.mu.Lock()
+			// an old bucket is not handed over while the sink is behind, Push will do it with the backpressure
+			if !b.closed && b.count > 0 && now.Sub(b.started) >= b.maxAge && !b.behind() {
+				b.handOver()
+			}
+			b.mu.Unlock()
+		}
+	}
+}
+
+// behind reports whether the count of buckets handed over and not processed yet reached the max in-flight limit.
+// It must be called under the lock.
+func (b *Batcher[T]) behind() bool {
+	b.state.Lock()
+	defer b.state.Unlock()
+	return b.sent-b.done > b.maxInFlight
+}
+
+// wait blocks until the sink processes the given count of buckets.
+func (b *Batcher[T]) wait(ctx context.Context, target uint64) error {
+	b.state.Lock()
+	for b.done < target {
+		progress := b.progress
+		b.state.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-progress:
+		}
+		b.state.Lock()
+	}
+	b.state.Unlock()
+	return nil
+}
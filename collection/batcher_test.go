@@ -0,0 +1,207 @@
+package collection
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iv-menshenin/fusion/errors"
+)
+
+func TestBatcher(t *testing.T) {
+	t.Parallel()
+	t.Run("full_buckets", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			mu      sync.Mutex
+			batches [][]int
+		)
+		b := NewBatcher[int](10, 0, 2, func(batch []int) {
+			mu.Lock()
+			batches = append(batches, append([]int(nil), batch...))
+			mu.Unlock()
+		})
+		for n := 0; n < 35; n++ {
+			b.Push(n)
+		}
+		require.NoError(t, b.Flush(context.Background()))
+		mu.Lock()
+		require.Len(t, batches, 4)
+		require.Len(t, batches[0], 10)
+		require.Len(t, batches[3], 5)
+		require.Equal(t, 34, batches[3][4])
+		mu.Unlock()
+
+		require.NoError(t, b.Close())
+		require.ErrorIs(t, b.Close(), errors.ErrClosed)
+		require.Panics(t, func() {
+			b.Push(1)
+		})
+	})
+	t.Run("max_age", func(t *testing.T) {
+		t.Parallel()
+
+		got := make(chan []int, 1)
+		b := NewBatcher[int](100, 20*time.Millisecond, 1, func(batch []int) {
+			got <- append([]int(nil), batch...)
+		})
+		defer b.Close()
+		b.Push(1)
+		b.Push(2)
+		select {
+		case batch := <-got:
+			require.Equal(t, []int{1, 2}, batch)
+		case <-time.After(time.Second):
+			t.Fatal("the bucket was not flushed by age")
+		}
+	})
+	t.Run("backpressure", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			release = make(chan struct{})
+			pushed  = make(chan struct{})
+		)
+		b := NewBatcher[int](1, 0, 1, func([]int) {
+			<-release
+		})
+		go func() {
+			// the first bucket is in the sink, the second one is queued, the third one must wait
+			for n := 0; n < 3; n++ {
+				b.Push(n)
+			}
+			close(pushed)
+		}()
+		select {
+		case <-pushed:
+			t.Fatal("push must be blocked")
+		case <-time.After(50 * time.Millisecond):
+		}
+		close(release)
+		<-pushed
+		require.NoError(t, b.Close())
+	})
+	t.Run("flush_timeout", func(t *testing.T) {
+		t.Parallel()
+
+		release := make(chan struct{})
+		b := NewBatcher[int](0, 0, 1, func([]int) {
+			<-release
+		})
+		b.Push(1)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, b.Flush(ctx), context.DeadlineExceeded)
+		close(release)
+		require.NoError(t, b.Close())
+	})
+	t.Run("flush_timeout_backpressure", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			release = make(chan struct{})
+			mu      sync.Mutex
+			got     []int
+		)
+		b := NewBatcher[int](2, 0, 1, func(batch []int) {
+			<-release
+			mu.Lock()
+			got = append(got, batch...)
+			mu.Unlock()
+		})
+		// the first bucket is in the sink, the second one fills the queue, the third one can not be handed over
+		for n := 0; n < 5; n++ {
+			b.Push(n)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		done := make(chan error)
+		go func() {
+			done <- b.Flush(ctx)
+		}()
+		select {
+		case err := <-done:
+			require.ErrorIs(t, err, context.DeadlineExceeded)
+		case <-time.After(time.Second):
+			t.Fatal("flush must respect the deadline")
+		}
+		close(release)
+		require.NoError(t, b.Close())
+		require.Equal(t, []int{0, 1, 2, 3, 4}, got)
+	})
+	t.Run("flush_timeout_blocked_push", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			release = make(chan struct{})
+			pushed  = make(chan struct{})
+			mu      sync.Mutex
+			got     []int
+		)
+		b := NewBatcher[int](1, 0, 1, func(batch []int) {
+			<-release
+			mu.Lock()
+			got = append(got, batch...)
+			mu.Unlock()
+		})
+		go func() {
+			// the third push waits for the backpressure
+			for n := 0; n < 3; n++ {
+				b.Push(n)
+			}
+			close(pushed)
+		}()
+		time.Sleep(20 * time.Millisecond)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		done := make(chan error)
+		go func() {
+			done <- b.Flush(ctx)
+		}()
+		select {
+		case err := <-done:
+			require.ErrorIs(t, err, context.DeadlineExceeded)
+		case <-time.After(500 * time.Millisecond):
+			t.Fatal("flush must respect the deadline while a producer is blocked")
+		}
+		select {
+		case <-pushed:
+			t.Fatal("push must be blocked")
+		default:
+		}
+		close(release)
+		<-pushed
+		require.NoError(t, b.Close())
+		require.Equal(t, []int{0, 1, 2}, got)
+	})
+	t.Run("concurrent", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			mu    sync.Mutex
+			total int
+			wg    sync.WaitGroup
+		)
+		b := NewBatcher[int](64, time.Millisecond, 4, func(batch []int) {
+			mu.Lock()
+			total += len(batch)
+			mu.Unlock()
+		})
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for n := 0; n < 10_000; n++ {
+					b.Push(n)
+				}
+			}()
+		}
+		wg.Wait()
+		require.NoError(t, b.Close())
+		require.Equal(t, 80_000, total)
+	})
+}
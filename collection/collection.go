@@ -209,13 +209,30 @@ func (c *Collection[T]) Prune() {
 	if c.bsz == 0 {
 		c.initBucketSize(defaultBucketSz)
 	}
-	bId := c.len / c.bsz
-	for n := bId + 1; n < len(c.buckets); n++ {
+	// keep the partially filled bucket
+	bId := (c.len + c.bsz - 1) / c.bsz
+	for n := bId; n < len(c.buckets); n++ {
 		c.buckets[n] = nil
 	}
 	c.buckets = c.buckets[:bId]
 }
 
+// Reset removes all the elements from the Collection, but keeps the allocated buckets for reuse,
+// so the subsequent pushes do not allocate memory. The cells are cleared, so the removed values can be collected.
+func (c *Collection[T]) Reset() {
+	var empty T
+	for bId := 0; c.len > 0; bId++ {
+		data := c.buckets[bId].data
+		if c.len < len(data) {
+			data = data[:c.len]
+		}
+		for xId := range data {
+			data[xId] = empty
+		}
+		c.len -= len(data)
+	}
+}
+
 // Each iterates through all the elements in the Collection and calls the provided callback function for each of
 // the elements. If the callback function returns false, the iteration will be stopped.
 func (c *Collection[T]) Each(callback func(*T) bool) {
@@ -212,6 +212,14 @@ func TestFusionCollectionPrune(t *testing.T) {
 	for n := 0; n < len(x); n++ {
 		require.Equal(t, n >= 10, x[n], "bad %d", n)
 	}
+
+	// the partially filled bucket is kept
+	c.Pop()
+	c.Prune()
+	require.Len(t, c.buckets, 9)
+	require.Equal(t, 89, c.Len())
+	c.Push(100)
+	require.Equal(t, 100, *c.Get(89))
 }
 
 func TestFusionCollectionEach(t *testing.T) {
@@ -0,0 +1,41 @@
+package collection
+
+import "sync/atomic"
+
+// DoubleBuffer is a pair of Collections for frame-based processing: producers write the events of the current frame
+// to the Write side while consumers read the events of the previous frame from the Read side.
+//
+// Swap makes the written side readable and turns the old read side into the new write side. The old read side
+// is Reset, so its buckets are reused and a steady frame loop does not allocate memory.
+//
+// The sides are switched atomically, but Swap must not be called while someone is still writing or reading,
+// i.e. it should be called between the frames.
+type DoubleBuffer[T any] struct {
+	sides [2]*Collection[T]
+	write uint32
+}
+
+// NewDoubleBuffer creates a new DoubleBuffer, both sides use the specified bucket size.
+// If the size is zero, the default value will be used.
+func NewDoubleBuffer[T any](bucketSz int) *DoubleBuffer[T] {
+	return &DoubleBuffer[T]{
+		sides: [2]*Collection[T]{New[T](bucketSz), New[T](bucketSz)},
+	}
+}
+
+// Write returns the side that collects the events of the current frame.
+func (d *DoubleBuffer[T]) Write() *Collection[T] {
+	return d.sides[atomic.LoadUint32(&d.write)]
+}
+
+// Read returns the side that holds the events of the previous frame.
+func (d *DoubleBuffer[T]) Read() *Collection[T] {
+	return d.sides[1-atomic.LoadUint32(&d.write)]
+}
+
+// Swap makes the current write side readable and resets the old read side to receive the next frame.
+func (d *DoubleBuffer[T]) Swap() {
+	w := atomic.LoadUint32(&d.write)
+	d.sides[1-w].Reset()
+	atomic.StoreUint32(&d.write, 1-w)
+}
@@ -0,0 +1,60 @@
+package collection
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoubleBuffer(t *testing.T) {
+	d := NewDoubleBuffer[int](4)
+	for n := 0; n < 10; n++ {
+		d.Write().Push(n)
+	}
+	require.Equal(t, 0, d.Read().Len())
+
+	d.Swap()
+	require.Equal(t, 10, d.Read().Len())
+	require.Equal(t, 0, d.Write().Len())
+	for n := 0; n < 10; n++ {
+		require.Equal(t, n, *d.Read().Get(n))
+	}
+
+	d.Write().Push(100)
+	d.Swap()
+	require.Equal(t, 1, d.Read().Len())
+	require.Equal(t, 100, *d.Read().Get(0))
+	// buckets of the old read side are kept for reuse
+	require.Equal(t, 0, d.Write().Len())
+	require.Len(t, d.Write().buckets, 3)
+
+	t.Run("no_allocs", func(t *testing.T) {
+		allocs := testing.AllocsPerRun(100, func() {
+			for n := 0; n < 12; n++ {
+				d.Write().Push(n)
+			}
+			d.Swap()
+		})
+		require.Zero(t, allocs)
+	})
+}
+
+func TestCollectionReset(t *testing.T) {
+	t.Parallel()
+	c := New[*int](10)
+	for n := 0; n < 25; n++ {
+		v := n
+		c.Push(&v)
+	}
+	c.Reset()
+	require.Equal(t, 0, c.Len())
+	require.Len(t, c.buckets, 3)
+	for _, b := range c.buckets {
+		for _, v := range b.data {
+			require.Nil(t, v)
+		}
+	}
+	v := 42
+	c.Push(&v)
+	require.Equal(t, 42, **c.Get(0))
+}
@@ -7,13 +7,13 @@ import "context"
 // If you need to prematurely terminate the iteration, call the cancel function of the context.
 func (c *Collection[T]) Iterator(ctx context.Context, buf int) <-chan *T {
 	ch := make(chan *T, buf)
-	go c.sendElements(ctx, ch)
+	go sendElements[T](ctx, ch, c.Each)
 	return ch
 }
 
-func (c *Collection[T]) sendElements(ctx context.Context, ch chan<- *T) {
+func sendElements[T any](ctx context.Context, ch chan<- *T, each func(callback func(*T) bool)) {
 	defer close(ch)
-	c.Each(func(val *T) bool {
+	each(func(val *T) bool {
 		select {
 		case <-ctx.Done():
 			return false
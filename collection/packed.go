@@ -0,0 +1,188 @@
+package collection
+
+import (
+	"math/bits"
+
+	"github.com/iv-menshenin/fusion/errors"
+)
+
+type (
+	// Integer is a constraint for the types that can be stored in the Packed collection.
+	Integer interface {
+		~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+	}
+	// Packed is a Collection of integers where each bucket is bit-packed to the minimal width
+	// that fits all its values. When a wider value arrives, only the bucket that receives it is re-packed.
+	//
+	// Signed values are zigzag-encoded, so small negative numbers are as cheap as small positive ones.
+	//
+	// In delta mode (see NewDelta) every value is stored as the difference with the first value of its bucket, which
+	// makes sorted IDs or timestamps very compact: the width depends on the spread of a bucket, not on the magnitude.
+	//
+	// Values can not be referenced, so Get returns a copy instead of a reference.
+	Packed[T Integer] struct {
+		len     int
+		bsz     int
+		delta   bool
+		buckets []*packedBucket
+	}
+	packedBucket struct {
+		width int
+		base  uint64
+		words []uint64
+	}
+)
+
+// NewPacked creates a new Packed collection with the specified bucket size.
+// If the size is zero, the default value will be used.
+func NewPacked[T Integer](bucketSz int) *Packed[T] {
+	if bucketSz == 0 {
+		bucketSz = defaultBucketSz
+	}
+	return &Packed[T]{bsz: bucketSz}
+}
+
+// NewDelta creates a new Packed collection in delta mode, which is the best choice for sorted or clustered data.
+// If the bucket size is zero, the default value will be used.
+func NewDelta[T Integer](bucketSz int) *Packed[T] {
+	c := NewPacked[T](bucketSz)
+	c.delta = true
+	return c
+}
+
+func (c *Packed[T]) Len() int {
+	return c.len
+}
+
+// Push adds a new value to the end of the Packed collection.
+func (c *Packed[T]) Push(val T) {
+	if c.bsz == 0 {
+		c.bsz = defaultBucketSz
+	}
+	bId, xId := c.len/c.bsz, c.len%c.bsz
+	if len(c.buckets) <= bId {
+		b := packedBucket{}
+		if c.delta {
+			b.base = uint64(val)
+		}
+		c.buckets = append(c.buckets, &b)
+	}
+	b := c.buckets[bId]
+	u := c.encode(b, val)
+	if w := bits.Len64(u); w > b.width {
+		c.repack(b, w, xId)
+	}
+	b.set(xId, u)
+	c.len++
+}
+
+// Get returns the value located at the position `id`.
+func (c *Packed[T]) Get(id int) T {
+	if id < 0 || id >= c.len {
+		panic(errors.OutOfBounds(c.len, id))
+	}
+	b := c.buckets[id/c.bsz]
+	return c.decode(b, b.get(id%c.bsz))
+}
+
+// Each iterates through all the values in the Packed collection and calls the provided callback function for each
+// of them. If the callback function returns false, the iteration will be stopped.
+func (c *Packed[T]) Each(callback func(T) bool) {
+	left := c.len
+	for _, b := range c.buckets {
+		for xId := 0; xId < c.bsz && left > 0; xId, left = xId+1, left-1 {
+			if !callback(c.decode(b, b.get(xId))) {
+				return
+			}
+		}
+	}
+}
+
+// Size returns the count of bytes occupied by the packed values.
+func (c *Packed[T]) Size() int {
+	var sz int
+	for _, b := range c.buckets {
+		sz += len(b.words) * 8
+	}
+	return sz
+}
+
+func (c *Packed[T]) encode(b *packedBucket, val T) uint64 {
+	if c.delta {
+		return zigzag(int64(uint64(val) - b.base))
+	}
+	if ^T(0) < 0 {
+		return zigzag(int64(val))
+	}
+	return uint64(val)
+}
+
+func (c *Packed[T]) decode(b *packedBucket, u uint64) T {
+	if c.delta {
+		return T(b.base + uint64(unzigzag(u)))
+	}
+	if ^T(0) < 0 {
+		return T(unzigzag(u))
+	}
+	return T(u)
+}
+
+// repack rewrites `count` values of the bucket using the new width.
+func (c *Packed[T]) repack(b *packedBucket, width, count int) {
+	n := packedBucket{
+		width: width,
+		base:  b.base,
+		words: make([]uint64, (c.bsz*width+63)/64),
+	}
+	for xId := 0; xId < count; xId++ {
+		n.set(xId, b.get(xId))
+	}
+	*b = n
+}
+
+func (b *packedBucket) get(xId int) uint64 {
+	if b.width == 0 {
+		return 0
+	}
+	var (
+		pos = xId * b.width
+		wId = pos / 64
+		off = pos % 64
+		val = b.words[wId] >> off
+	)
+	if off+b.width > 64 {
+		val |= b.words[wId+1] << (64 - off)
+	}
+	if b.width < 64 {
+		val &= (1 << b.width) - 1
+	}
+	return val
+}
+
+func (b *packedBucket) set(xId int, val uint64) {
+	if b.width == 0 {
+		return
+	}
+	var (
+		pos  = xId * b.width
+		wId  = pos / 64
+		off  = pos % 64
+		mask = ^uint64(0)
+	)
+	if b.width < 64 {
+		mask = (1 << b.width) - 1
+	}
+	b.words[wId] = b.words[wId]&^(mask<<off) | val<<off
+	if off+b.width > 64 {
+		rest := 64 - off
+		b.words[wId+1] = b.words[wId+1]&^(mask>>rest) | val>>rest
+	}
+}
+
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+func unzigzag(u uint64) int64 {
+	return int64(u>>1) ^ -int64(u&1)
+}
@@ -0,0 +1,117 @@
+package collection
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacked(t *testing.T) {
+	t.Parallel()
+	t.Run("uint32", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewPacked[uint32](64)
+		rnd := rand.New(rand.NewSource(1))
+		var expected []uint32
+		for n := 0; n < 10_000; n++ {
+			v := uint32(rnd.Intn(1 << (n % 32)))
+			expected = append(expected, v)
+			c.Push(v)
+		}
+		require.Equal(t, len(expected), c.Len())
+		for n, v := range expected {
+			require.Equal(t, v, c.Get(n))
+		}
+	})
+	t.Run("signed", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewPacked[int64](0)
+		values := []int64{0, -1, 1, -2, 2, math.MaxInt64, math.MinInt64, 42, -42}
+		for _, v := range values {
+			c.Push(v)
+		}
+		for n, v := range values {
+			require.Equal(t, v, c.Get(n))
+		}
+		require.Equal(t, 64, c.buckets[0].width)
+	})
+	t.Run("narrow", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewPacked[uint64](1024)
+		for n := 0; n < 1024; n++ {
+			c.Push(uint64(n % 8))
+		}
+		require.Equal(t, 3, c.buckets[0].width)
+		require.Equal(t, 1024*3/8, c.Size())
+
+		c.Push(0)
+		require.Equal(t, 0, c.buckets[1].width)
+		require.Equal(t, uint64(0), c.Get(1024))
+	})
+	t.Run("delta", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewDelta[uint64](128)
+		const start = 1_700_000_000_000
+		for n := 0; n < 1000; n++ {
+			c.Push(start + uint64(n*10))
+		}
+		require.LessOrEqual(t, c.buckets[0].width, 12)
+		for n := 0; n < 1000; n++ {
+			require.Equal(t, start+uint64(n*10), c.Get(n))
+		}
+		// not sorted value in delta mode
+		c.Push(1)
+		require.Equal(t, uint64(1), c.Get(1000))
+	})
+	t.Run("each", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewDelta[int](10)
+		for n := 0; n < 95; n++ {
+			c.Push(-n)
+		}
+		var i int
+		c.Each(func(v int) bool {
+			require.Equal(t, -i, v)
+			i++
+			return true
+		})
+		require.Equal(t, 95, i)
+	})
+	t.Run("out_of_bounds", func(t *testing.T) {
+		t.Parallel()
+
+		var c Packed[uint8]
+		c.Push(1)
+		require.Panics(t, func() {
+			c.Get(1)
+		})
+	})
+}
+
+func BenchmarkPacked(b *testing.B) {
+	b.Run("Push", func(b *testing.B) {
+		b.ReportAllocs()
+		c := NewDelta[uint32](0)
+		for n := 0; n < b.N; n++ {
+			c.Push(uint32(n))
+		}
+	})
+	b.Run("Get", func(b *testing.B) {
+		c := NewPacked[uint32](0)
+		for n := 0; n < 1_000_000; n++ {
+			c.Push(uint32(n))
+		}
+		b.ResetTimer()
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			_ = c.Get(n % 1_000_000)
+		}
+	})
+}
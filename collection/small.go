@@ -0,0 +1,225 @@
+package collection
+
+import (
+	"context"
+	"math/bits"
+
+	"github.com/iv-menshenin/fusion/errors"
+)
+
+// Small is a mode of Collection for the cases when most of the instances hold only a few elements.
+//
+// The first elements are stored in an array inside the Small struct itself, so a tiny collection does not allocate
+// at all. When the inline array overflows, the data spills into buckets whose size grows geometrically, starting from
+// twice the inline size up to the bucket size passed to NewSmall. From then on Small behaves as Collection.
+//
+// The semantics of all the methods are the same as for Collection, including the reference validity rules.
+//
+// Small is a separate type rather than a mode of Collection, so the large collections do not pay for the inline array
+// and the addressing of Collection stays a plain bit shift.
+type Small[T any] struct {
+	len     int
+	bsz     int
+	geoN    int // count of geometrically growing buckets
+	geoLen  int // total capacity of geometrically growing buckets
+	inline  [smallInlineSz]T
+	buckets []*bucket[T]
+}
+
+const (
+	smallInlineSz    = 16
+	smallFirstBucket = smallInlineSz * 2
+)
+
+// NewSmall creates a new Small collection. The bucket size is the maximum size that spilled buckets can grow to.
+// If the size is zero, the default value will be used.
+func NewSmall[T any](bucketSz int) *Small[T] {
+	var c Small[T]
+	c.initBucketSize(bucketSz)
+	return &c
+}
+
+// InitSmall creates a Small collection with pre-fulfilled data. Unlike Init, the data is always copied,
+// since the inline array and the growing buckets can not reuse the slice.
+func InitSmall[T any](val []T, bucketSz int) *Small[T] {
+	c := NewSmall[T](bucketSz)
+	for _, v := range val {
+		c.Push(v)
+	}
+	return c
+}
+
+func (c *Small[T]) initBucketSize(bsz int) {
+	if bsz == 0 {
+		bsz = defaultBucketSz
+	}
+	c.bsz = bsz
+	c.geoN, c.geoLen = 0, 0
+	for sz := smallFirstBucket; sz < bsz; sz <<= 1 {
+		c.geoN++
+		c.geoLen += sz
+	}
+}
+
+func (c *Small[T]) Len() int {
+	return c.len
+}
+
+// locate returns the reference to the cell with the given index, the buckets must already be allocated.
+func (c *Small[T]) locate(id int) *T {
+	if id < smallInlineSz {
+		return &c.inline[id]
+	}
+	id -= smallInlineSz
+	if id < c.geoLen {
+		bId := bits.Len(uint(id/smallFirstBucket+1)) - 1
+		xId := id - smallFirstBucket*((1<<bId)-1)
+		return &c.buckets[bId].data[xId]
+	}
+	id -= c.geoLen
+	return &c.buckets[c.geoN+id/c.bsz].data[id%c.bsz]
+}
+
+// bucketSize returns the capacity of the bucket with the given number.
+func (c *Small[T]) bucketSize(bId int) int {
+	if bId < c.geoN {
+		return smallFirstBucket << bId
+	}
+	return c.bsz
+}
+
+// capacity returns the count of elements that fit into the inline array and the first `n` buckets.
+func (c *Small[T]) capacity(n int) int {
+	if n <= c.geoN {
+		return smallInlineSz + smallFirstBucket*((1<<n)-1)
+	}
+	return smallInlineSz + c.geoLen + (n-c.geoN)*c.bsz
+}
+
+// Push adds a new value to the end of the Small collection and returns a reference to it.
+func (c *Small[T]) Push(val T) *T {
+	if c.bsz == 0 {
+		c.initBucketSize(defaultBucketSz)
+	}
+	if c.len >= c.capacity(len(c.buckets)) {
+		c.buckets = append(c.buckets, &bucket[T]{
+			data: make([]T, c.bucketSize(len(c.buckets))),
+		})
+	}
+	ref := c.locate(c.len)
+	c.len++
+	*ref = val
+	return ref
+}
+
+// Get allows you to get a reference to an object located in a Small collection.
+//
+// Avoid storing the link outside the collection for long periods of time.
+func (c *Small[T]) Get(id int) *T {
+	if id >= c.len {
+		return nil
+	}
+	return c.locate(id)
+}
+
+// Delete deletes an object by its index from the collection, replacing it with the last object.
+// See Collection.Delete for details.
+func (c *Small[T]) Delete(id int) {
+	if id >= c.len {
+		panic(errors.OutOfBounds(c.len, id))
+	}
+	c.len--
+	last := c.locate(c.len)
+	if id != c.len {
+		// swap
+		*c.locate(id) = *last
+	}
+	// clear cell
+	var empty T
+	*last = empty
+}
+
+// Pop selects the last item in the collection and returns a copy of it. The original item is deleted.
+func (c *Small[T]) Pop() T {
+	if c.len < 1 {
+		panic(errors.OutOfBounds(c.len, 0))
+	}
+	c.len--
+	last := c.locate(c.len)
+	val := *last
+	// clean cell
+	var empty T
+	*last = empty
+	return val
+}
+
+// Prune clears unoccupied space. It can be used after a large number of calls to Delete or Pop method.
+// When all the elements fit into the inline array, all the buckets are released.
+func (c *Small[T]) Prune() {
+	var n int
+	for c.capacity(n) < c.len {
+		n++
+	}
+	for x := n; x < len(c.buckets); x++ {
+		c.buckets[x] = nil
+	}
+	c.buckets = c.buckets[:n]
+}
+
+// Reset removes all the elements from the collection, but keeps the allocated buckets for reuse.
+// The cells are cleared, so the removed values can be collected.
+func (c *Small[T]) Reset() {
+	var empty T
+	c.Each(func(val *T) bool {
+		*val = empty
+		return true
+	})
+	c.len = 0
+}
+
+// Iterator returns a chan-iterator for iterating over all elements, see Collection.Iterator.
+func (c *Small[T]) Iterator(ctx context.Context, buf int) <-chan *T {
+	ch := make(chan *T, buf)
+	go sendElements[T](ctx, ch, c.Each)
+	return ch
+}
+
+// Fetcher allows for a sequential traversal of all elements in the collection, see Collection.Fetcher.
+func (c *Small[T]) Fetcher() *SmallFetcher[T] {
+	return &SmallFetcher[T]{c: c}
+}
+
+// SmallFetcher allows for a sequential traversal of all elements in the Small collection.
+type SmallFetcher[T any] struct {
+	c *Small[T]
+	i int
+}
+
+// Next advances the cursor forward, returning true if the end has not yet been reached; otherwise, it returns false.
+func (f *SmallFetcher[T]) Next() bool {
+	f.i++
+	return f.i <= f.c.Len()
+}
+
+// Fetch allows access to the current element of the collection. It must not be called before the first Next.
+func (f *SmallFetcher[T]) Fetch() *T {
+	return f.c.Get(f.i - 1)
+}
+
+// Each iterates through all the elements in the collection and calls the provided callback function for each of
+// the elements. If the callback function returns false, the iteration will be stopped.
+func (c *Small[T]) Each(callback func(*T) bool) {
+	left := c.len
+	for x := 0; x < smallInlineSz && left > 0; x, left = x+1, left-1 {
+		if !callback(&c.inline[x]) {
+			return
+		}
+	}
+	for _, b := range c.buckets {
+		for x := 0; x < len(b.data) && left > 0; x, left = x+1, left-1 {
+			if !callback(&b.data[x]) {
+				return
+			}
+		}
+	}
+}
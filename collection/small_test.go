@@ -0,0 +1,156 @@
+package collection
+
+import (
+	"context"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSmall(t *testing.T) {
+	t.Parallel()
+	t.Run("inline", func(t *testing.T) {
+		t.Parallel()
+
+		var c Small[int]
+		for n := 0; n < smallInlineSz; n++ {
+			c.Push(n)
+		}
+		require.Equal(t, smallInlineSz, c.Len())
+		require.Len(t, c.buckets, 0)
+		for n := 0; n < smallInlineSz; n++ {
+			require.Equal(t, n, *c.Get(n))
+		}
+		require.Nil(t, c.Get(smallInlineSz))
+
+		c.Push(smallInlineSz)
+		require.Len(t, c.buckets, 1)
+		require.Len(t, c.buckets[0].data, smallFirstBucket)
+	})
+	t.Run("geometric", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewSmall[int](256)
+		const count = 10_000
+		for n := 0; n < count; n++ {
+			require.Equal(t, n, c.Len())
+			c.Push(n)
+		}
+		require.Len(t, c.buckets[0].data, 32)
+		require.Len(t, c.buckets[1].data, 64)
+		require.Len(t, c.buckets[2].data, 128)
+		require.Len(t, c.buckets[3].data, 256)
+		require.Len(t, c.buckets[4].data, 256)
+		for n := 0; n < count; n++ {
+			require.Equal(t, n, *c.Get(n))
+		}
+		var i int
+		c.Each(func(v *int) bool {
+			require.Equal(t, i, *v)
+			i++
+			return true
+		})
+		require.Equal(t, count, i)
+	})
+	t.Run("not_power_of_two", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewSmall[int](100)
+		const count = 1_000
+		for n := 0; n < count; n++ {
+			c.Push(n)
+		}
+		require.Len(t, c.buckets[2].data, 100)
+		for n := 0; n < count; n++ {
+			require.Equal(t, n, *c.Get(n))
+		}
+	})
+	t.Run("delete_pop_prune", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewSmall[int](64)
+		for n := 0; n < 200; n++ {
+			c.Push(n)
+		}
+		c.Delete(0)
+		require.Equal(t, 199, *c.Get(0))
+		for n := 198; n > 9; n-- {
+			require.Equal(t, n, c.Pop())
+		}
+		require.Equal(t, 10, c.Len())
+		c.Prune()
+		require.Len(t, c.buckets, 0)
+		require.Equal(t, 199, *c.Get(0))
+		require.Equal(t, 9, *c.Get(9))
+	})
+	t.Run("init_reset", func(t *testing.T) {
+		t.Parallel()
+
+		val := make([]int, 100)
+		for n := range val {
+			val[n] = n
+		}
+		c := InitSmall(val, 64)
+		require.Equal(t, 100, c.Len())
+		val[0] = -1
+		require.Equal(t, 0, *c.Get(0))
+		require.Equal(t, 99, *c.Get(99))
+
+		buckets := len(c.buckets)
+		c.Reset()
+		require.Equal(t, 0, c.Len())
+		require.Len(t, c.buckets, buckets)
+		require.Equal(t, 0, c.buckets[0].data[0])
+		c.Push(7)
+		require.Equal(t, 7, *c.Get(0))
+	})
+	t.Run("iterator_fetcher", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewSmall[int](32)
+		for n := 0; n < 100; n++ {
+			c.Push(n)
+		}
+		var i int
+		for x := range c.Iterator(context.Background(), 4) {
+			require.Equal(t, i, *x)
+			i++
+		}
+		require.Equal(t, 100, i)
+
+		i = 0
+		f := c.Fetcher()
+		for f.Next() {
+			require.Equal(t, i, *f.Fetch())
+			i++
+		}
+		require.Equal(t, 100, i)
+	})
+}
+
+func TestSmallSize(t *testing.T) {
+	var c Small[int64]
+	require.Less(t, int(unsafe.Sizeof(c)), 8*smallInlineSz+64)
+}
+
+func BenchmarkSmall(b *testing.B) {
+	b.Run("Tiny", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			var c Small[int]
+			for i := 0; i < 8; i++ {
+				c.Push(i)
+			}
+		}
+	})
+	b.Run("Push_1M", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			var c Small[int]
+			for i := 0; i < 1_000_000; i++ {
+				c.Push(i)
+			}
+		}
+	})
+}
@@ -16,3 +16,9 @@ func (e ErrOutOfBounds) Error() string {
 func OutOfBounds(l, i int) error {
 	return ErrOutOfBounds{l: l, i: i}
 }
+
+// ErrClosed is returned when the structure is used after it has been closed.
+var ErrClosed = fmt.Errorf("use of closed structure")
+
+// ErrTooLarge is returned when the data does not fit into the structure.
+var ErrTooLarge = fmt.Errorf("data is too large")
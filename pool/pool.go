@@ -0,0 +1,228 @@
+package pool
+
+import (
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/iv-menshenin/fusion/stack"
+)
+
+// Pool is a typed pool of reusable objects, a deterministic alternative to sync.Pool: idle objects are kept
+// in stack.Stack buckets until they are taken, and they are never dropped by the garbage collector.
+//
+// The idle objects are spread between shards, each protected by its own mutex and keeping its own statistics.
+// The home shard of a call is taken from a hint cached per P, so the goroutines running on the same P share
+// a shard and the goroutines on different Ps usually do not contend. When the home shard is empty, Get steals
+// an idle object from another shard, and when it is full, Put gives the object to another shard.
+//
+// The count of idle objects is limited by the max idle size, which is divided between the shards.
+// An object is dropped by Put only if all the shards are full.
+type Pool[T any] struct {
+	newFn  func() *T
+	reset  func(*T)
+	shards []shard[T]
+	hints  sync.Pool // *int indices of the home shards, cached per P
+	next   uint32    // the shard of the next new hint
+}
+
+type shard[T any] struct {
+	mu    sync.Mutex
+	objs  stack.Stack[*T]
+	limit int // max idle objects of the shard
+
+	// updated atomically, so they can be read without the lock
+	idle   int64
+	hits   int64
+	misses int64
+	puts   int64
+
+	_ [64]byte // avoid false sharing between shards
+}
+
+// Stats is the snapshot of the pool statistics.
+type Stats struct {
+	Hits   int64 // Get calls served with an idle object
+	Misses int64 // Get calls that created a new object
+	Live   int64 // objects taken with Get and not returned with Put yet
+	Idle   int64 // objects waiting in the pool
+}
+
+// New creates a new Pool with one shard per P. The `newFn` function creates an object when there are no idle ones,
+// the optional `reset` function is called for each object returned with Put. Zero max idle size means unlimited pool.
+func New[T any](maxIdle int, newFn func() *T, reset func(*T)) *Pool[T] {
+	p := Pool[T]{
+		newFn:  newFn,
+		reset:  reset,
+		shards: make([]shard[T], runtime.GOMAXPROCS(0)),
+	}
+	for i := range p.shards {
+		limit := math.MaxInt
+		if maxIdle > 0 {
+			limit = maxIdle / len(p.shards)
+			if i < maxIdle%len(p.shards) {
+				limit++
+			}
+		}
+		p.shards[i].limit = limit
+	}
+	return &p
+}
+
+// Get takes an idle object from the pool or creates a new one.
+func (p *Pool[T]) Get() *T {
+	home := p.home()
+	s := &p.shards[home]
+	if obj := p.take(home); obj != nil {
+		atomic.AddInt64(&s.hits, 1)
+		return obj
+	}
+	atomic.AddInt64(&s.misses, 1)
+	return p.newFn()
+}
+
+// Put resets the object and returns it to the pool. The object is dropped if the pool is full.
+func (p *Pool[T]) Put(obj *T) {
+	if obj == nil {
+		return
+	}
+	if p.reset != nil {
+		p.reset(obj)
+	}
+	home := p.home()
+	s := &p.shards[home]
+	atomic.AddInt64(&s.puts, 1)
+	s.mu.Lock()
+	if p.push(s, obj) {
+		return
+	}
+	var busy []int
+	for n := 1; n < len(p.shards); n++ {
+		i := (home + n) % len(p.shards)
+		s = &p.shards[i]
+		if atomic.LoadInt64(&s.idle) >= int64(s.limit) {
+			continue
+		}
+		if !s.mu.TryLock() {
+			busy = append(busy, i)
+			continue
+		}
+		if p.push(s, obj) {
+			return
+		}
+	}
+	for _, i := range busy {
+		s = &p.shards[i]
+		s.mu.Lock()
+		if p.push(s, obj) {
+			return
+		}
+	}
+}
+
+// Len returns the count of idle objects.
+func (p *Pool[T]) Len() int {
+	var idle int64
+	for i := range p.shards {
+		idle += atomic.LoadInt64(&p.shards[i].idle)
+	}
+	return int(idle)
+}
+
+// Stats returns the current statistics of the pool.
+func (p *Pool[T]) Stats() Stats {
+	var (
+		stats Stats
+		puts  int64
+	)
+	for i := range p.shards {
+		s := &p.shards[i]
+		stats.Hits += atomic.LoadInt64(&s.hits)
+		stats.Misses += atomic.LoadInt64(&s.misses)
+		stats.Idle += atomic.LoadInt64(&s.idle)
+		puts += atomic.LoadInt64(&s.puts)
+	}
+	stats.Live = stats.Hits + stats.Misses - puts
+	return stats
+}
+
+// Clear drops all the idle objects.
+func (p *Pool[T]) Clear() {
+	for i := range p.shards {
+		s := &p.shards[i]
+		s.mu.Lock()
+		s.objs.Clear()
+		atomic.StoreInt64(&s.idle, 0)
+		s.mu.Unlock()
+	}
+}
+
+// take pops an idle object from the home shard, or steals it from another one: first from the shards that can be
+// locked without waiting and then from the rest. Put gives the objects to other shards the same way. It returns nil if all the shards are empty.
+func (p *Pool[T]) take(home int) *T {
+	s := &p.shards[home]
+	s.mu.Lock()
+	if obj := p.pop(s); obj != nil {
+		return obj
+	}
+	var busy []int
+	for n := 1; n < len(p.shards); n++ {
+		i := (home + n) % len(p.shards)
+		s = &p.shards[i]
+		if atomic.LoadInt64(&s.idle) == 0 {
+			continue
+		}
+		if !s.mu.TryLock() {
+			busy = append(busy, i)
+			continue
+		}
+		if obj := p.pop(s); obj != nil {
+			return obj
+		}
+	}
+	for _, i := range busy {
+		s = &p.shards[i]
+		s.mu.Lock()
+		if obj := p.pop(s); obj != nil {
+			return obj
+		}
+	}
+	return nil
+}
+
+// pop takes an object from the locked shard and unlocks it.
+func (p *Pool[T]) pop(s *shard[T]) *T {
+	defer s.mu.Unlock()
+	if s.objs.Len() == 0 {
+		return nil
+	}
+	atomic.AddInt64(&s.idle, -1)
+	obj := *s.objs.Peek()
+	// Truncate clears the released cell, so the bucket does not keep the reference
+	s.objs.Truncate(s.objs.Len() - 1)
+	return obj
+}
+
+// push puts the object to the locked shard if it is not full, and unlocks it.
+func (p *Pool[T]) push(s *shard[T], obj *T) bool {
+	defer s.mu.Unlock()
+	if s.objs.Len() >= s.limit {
+		return false
+	}
+	s.objs.Push(obj)
+	atomic.AddInt64(&s.idle, 1)
+	return true
+}
+
+// home returns the home shard of the call. The hints are kept in a sync.Pool, which caches them per P,
+// so the calls on the same P get the same shard. A hint dropped at GC is replaced by the next shard in turn.
+func (p *Pool[T]) home() int {
+	h, _ := p.hints.Get().(*int)
+	if h == nil {
+		h = new(int)
+		*h = int(atomic.AddUint32(&p.next, 1) % uint32(len(p.shards)))
+	}
+	p.hints.Put(h)
+	return *h
+}
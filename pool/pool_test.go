@@ -0,0 +1,123 @@
+package pool
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type buffer struct {
+	data []byte
+}
+
+func newBufferPool(maxIdle int) *Pool[buffer] {
+	return New(maxIdle, func() *buffer {
+		return &buffer{data: make([]byte, 0, 64)}
+	}, func(b *buffer) {
+		b.data = b.data[:0]
+	})
+}
+
+func TestPool(t *testing.T) {
+	t.Parallel()
+	t.Run("reuse", func(t *testing.T) {
+		t.Parallel()
+
+		p := newBufferPool(0)
+		b := p.Get()
+		b.data = append(b.data, "hello"...)
+		p.Put(b)
+		require.Equal(t, 1, p.Len())
+
+		reused := p.Get()
+		require.Same(t, b, reused)
+		require.Empty(t, reused.data)
+		require.Equal(t, Stats{Hits: 1, Misses: 1, Live: 1, Idle: 0}, p.Stats())
+	})
+	t.Run("max_idle", func(t *testing.T) {
+		t.Parallel()
+
+		p := newBufferPool(3)
+		var taken []*buffer
+		for i := 0; i < 5; i++ {
+			taken = append(taken, p.Get())
+		}
+		for _, b := range taken {
+			p.Put(b)
+		}
+		require.Equal(t, Stats{Hits: 0, Misses: 5, Live: 0, Idle: 3}, p.Stats())
+		p.Clear()
+		require.Equal(t, 0, p.Len())
+	})
+	t.Run("put_to_busy_shard", func(t *testing.T) {
+		t.Parallel()
+
+		p := newBufferPool(2)
+		p.shards = make([]shard[buffer], 2)
+		for i := range p.shards {
+			p.shards[i].limit = 1
+		}
+		p.Put(p.Get())
+		// the shard with a free place is busy, Put must wait for it instead of dropping the object
+		free := &p.shards[0]
+		if atomic.LoadInt64(&free.idle) > 0 {
+			free = &p.shards[1]
+		}
+		free.mu.Lock()
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			free.mu.Unlock()
+		}()
+		p.Put(&buffer{})
+		require.Equal(t, 2, p.Len())
+		p.Put(&buffer{})
+		require.Equal(t, 2, p.Len())
+	})
+	t.Run("concurrent", func(t *testing.T) {
+		t.Parallel()
+
+		const (
+			workers = 8
+			rounds  = 10000
+		)
+		var (
+			p     = newBufferPool(workers)
+			wg    sync.WaitGroup
+			dirty int64
+		)
+		wg.Add(workers)
+		for w := 0; w < workers; w++ {
+			go func() {
+				defer wg.Done()
+				for i := 0; i < rounds; i++ {
+					b := p.Get()
+					if len(b.data) > 0 {
+						atomic.AddInt64(&dirty, 1)
+					}
+					b.data = append(b.data, 1)
+					p.Put(b)
+				}
+			}()
+		}
+		wg.Wait()
+		require.Zero(t, dirty, "buffers are not reset")
+		stats := p.Stats()
+		require.Equal(t, int64(workers*rounds), stats.Hits+stats.Misses)
+		require.Equal(t, int64(0), stats.Live)
+		require.LessOrEqual(t, stats.Idle, int64(workers))
+	})
+}
+
+func BenchmarkPool(b *testing.B) {
+	p := newBufferPool(0)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			buf := p.Get()
+			buf.data = append(buf.data, 1)
+			p.Put(buf)
+		}
+	})
+}
@@ -0,0 +1,383 @@
+package sortedlist
+
+import (
+	"github.com/iv-menshenin/fusion/errors"
+	"github.com/iv-menshenin/fusion/tree"
+)
+
+type (
+	// SortedList is an always-sorted sequence of values. Like Collection it stores data in a chain of buckets,
+	// but here each bucket is kept sorted: a bucket is split in two when it overflows and merged with its neighbour
+	// when it becomes too small.
+	//
+	// Finding a bucket is a binary search over the bucket boundaries, and positional access (At, IndexOf) uses
+	// a Fenwick tree over the bucket lengths, so all the operations are logarithmic in the number of buckets.
+	// Insertion and removal also move the tail of a single bucket, which is cheap for reasonable bucket sizes.
+	//
+	// Values are returned by copy: modifying a stored value in place could break the order.
+	SortedList[T any] struct {
+		len     int
+		bsz     int
+		less    func(*T, *T) bool
+		buckets []*bucket[T]
+		// Fenwick tree over the bucket lengths, nil means it has to be rebuilt
+		index []int
+	}
+	bucket[T any] struct {
+		data []T
+	}
+)
+
+const defaultBucketSz = 1024
+
+// New creates a new SortedList of ordered values with the specified bucket size.
+// If the size is zero, the default value will be used.
+func New[T tree.Ordered](bucketSz int) *SortedList[T] {
+	return NewFunc[T](bucketSz, func(a, b *T) bool {
+		return *a < *b
+	})
+}
+
+// NewFunc creates a new SortedList with the specified bucket size which uses the comparator `less` to order values.
+// If the size is zero, the default value will be used.
+func NewFunc[T any](bucketSz int, less func(*T, *T) bool) *SortedList[T] {
+	if bucketSz < 2 {
+		bucketSz = defaultBucketSz
+	}
+	return &SortedList[T]{
+		bsz:  bucketSz,
+		less: less,
+	}
+}
+
+func (s *SortedList[T]) Len() int {
+	return s.len
+}
+
+// Add inserts the value into its position according to the order. Equal values are placed after the existing ones.
+func (s *SortedList[T]) Add(val T) {
+	if len(s.buckets) == 0 {
+		data := make([]T, 1, s.bsz)
+		data[0] = val
+		s.buckets = append(s.buckets, &bucket[T]{data: data})
+		s.index = nil
+		s.len++
+		return
+	}
+	bId := s.searchBucketRight(&val)
+	if bId == len(s.buckets) {
+		bId--
+	}
+	b := s.buckets[bId]
+	xId := s.searchRight(b.data, &val)
+	var empty T
+	b.data = append(b.data, empty)
+	copy(b.data[xId+1:], b.data[xId:])
+	b.data[xId] = val
+	s.len++
+	s.updateIndex(bId, 1)
+	if len(b.data) >= 2*s.bsz {
+		s.split(bId)
+	}
+}
+
+// Remove removes one occurrence of the value from the SortedList and returns true if the value was found.
+func (s *SortedList[T]) Remove(val T) bool {
+	bId, xId, ok := s.find(&val)
+	if !ok {
+		return false
+	}
+	s.deleteAt(bId, xId)
+	return true
+}
+
+// Delete removes the value located at position `i` and returns it.
+func (s *SortedList[T]) Delete(i int) T {
+	if i < 0 || i >= s.len {
+		panic(errors.OutOfBounds(s.len, i))
+	}
+	bId, xId := s.locate(i)
+	val := s.buckets[bId].data[xId]
+	s.deleteAt(bId, xId)
+	return val
+}
+
+// Has returns true if the value is present in the SortedList.
+func (s *SortedList[T]) Has(val T) bool {
+	_, _, ok := s.find(&val)
+	return ok
+}
+
+// IndexOf returns the position of the first occurrence of the value, or -1 if the value is not present.
+func (s *SortedList[T]) IndexOf(val T) int {
+	bId, xId, ok := s.find(&val)
+	if !ok {
+		return -1
+	}
+	return s.offset(bId) + xId
+}
+
+// At returns a copy of the value located at position `i`.
+func (s *SortedList[T]) At(i int) T {
+	if i < 0 || i >= s.len {
+		panic(errors.OutOfBounds(s.len, i))
+	}
+	bId, xId := s.locate(i)
+	return s.buckets[bId].data[xId]
+}
+
+// Bisect returns the position where the value would be inserted to keep the order, placing it before
+// any existing equal values. In other words, it is the count of values that are less than `val`.
+func (s *SortedList[T]) Bisect(val T) int {
+	bId := s.searchBucketLeft(&val)
+	if bId == len(s.buckets) {
+		return s.len
+	}
+	return s.offset(bId) + s.searchLeft(s.buckets[bId].data, &val)
+}
+
+// BisectRight is like Bisect, but places the value after any existing equal values.
+func (s *SortedList[T]) BisectRight(val T) int {
+	bId := s.searchBucketRight(&val)
+	if bId == len(s.buckets) {
+		return s.len
+	}
+	return s.offset(bId) + s.searchRight(s.buckets[bId].data, &val)
+}
+
+// Each iterates through all the values in ascending order and calls the provided callback function for each of
+// the values. If the callback function returns false, the iteration will be stopped.
+func (s *SortedList[T]) Each(callback func(T) bool) {
+	s.each(0, 0, s.len, callback)
+}
+
+// Range iterates through the values `v` such that `from <= v < to` in ascending order.
+// If the callback function returns false, the iteration will be stopped.
+func (s *SortedList[T]) Range(from, to T, callback func(T) bool) {
+	bId := s.searchBucketLeft(&from)
+	if bId == len(s.buckets) {
+		return
+	}
+	xId := s.searchLeft(s.buckets[bId].data, &from)
+	for ; bId < len(s.buckets); bId++ {
+		for _, val := range s.buckets[bId].data[xId:] {
+			if !s.less(&val, &to) {
+				return
+			}
+			if !callback(val) {
+				return
+			}
+		}
+		xId = 0
+	}
+}
+
+// Slice iterates through the values located at positions from `i` (inclusive) to `j` (exclusive).
+// If the callback function returns false, the iteration will be stopped.
+func (s *SortedList[T]) Slice(i, j int, callback func(T) bool) {
+	if i < 0 || i > s.len {
+		panic(errors.OutOfBounds(s.len, i))
+	}
+	if j > s.len {
+		j = s.len
+	}
+	if i >= j {
+		return
+	}
+	bId, xId := s.locate(i)
+	s.each(bId, xId, j-i, callback)
+}
+
+func (s *SortedList[T]) each(bId, xId, count int, callback func(T) bool) {
+	for ; bId < len(s.buckets) && count > 0; bId++ {
+		for _, val := range s.buckets[bId].data[xId:] {
+			if count--; count < 0 || !callback(val) {
+				return
+			}
+		}
+		xId = 0
+	}
+}
+
+func (s *SortedList[T]) find(val *T) (bId, xId int, ok bool) {
+	bId = s.searchBucketLeft(val)
+	if bId == len(s.buckets) {
+		return 0, 0, false
+	}
+	data := s.buckets[bId].data
+	xId = s.searchLeft(data, val)
+	if xId == len(data) || s.less(val, &data[xId]) {
+		return 0, 0, false
+	}
+	return bId, xId, true
+}
+
+func (s *SortedList[T]) deleteAt(bId, xId int) {
+	b := s.buckets[bId]
+	copy(b.data[xId:], b.data[xId+1:])
+	// clear cell
+	var empty T
+	b.data[len(b.data)-1] = empty
+	b.data = b.data[:len(b.data)-1]
+	s.len--
+	s.updateIndex(bId, -1)
+	if len(b.data) < s.bsz/2 {
+		s.merge(bId)
+	}
+}
+
+// searchBucketLeft returns the first bucket whose last value is not less than `val`.
+func (s *SortedList[T]) searchBucketLeft(val *T) int {
+	lo, hi := 0, len(s.buckets)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		data := s.buckets[mid].data
+		if s.less(&data[len(data)-1], val) {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// searchBucketRight returns the first bucket whose last value is greater than `val`.
+func (s *SortedList[T]) searchBucketRight(val *T) int {
+	lo, hi := 0, len(s.buckets)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		data := s.buckets[mid].data
+		if s.less(val, &data[len(data)-1]) {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo
+}
+
+func (s *SortedList[T]) searchLeft(data []T, val *T) int {
+	lo, hi := 0, len(data)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if s.less(&data[mid], val) {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+func (s *SortedList[T]) searchRight(data []T, val *T) int {
+	lo, hi := 0, len(data)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if s.less(val, &data[mid]) {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo
+}
+
+// split divides the overflowed bucket into two halves.
+func (s *SortedList[T]) split(bId int) {
+	b := s.buckets[bId]
+	half := len(b.data) / 2
+	sz := s.bsz
+	if sz < len(b.data)-half {
+		sz = len(b.data) - half
+	}
+	data := make([]T, len(b.data)-half, sz)
+	copy(data, b.data[half:])
+	var empty T
+	for n := half; n < len(b.data); n++ {
+		b.data[n] = empty
+	}
+	b.data = b.data[:half]
+
+	s.buckets = append(s.buckets, nil)
+	copy(s.buckets[bId+2:], s.buckets[bId+1:])
+	s.buckets[bId+1] = &bucket[T]{data: data}
+	s.index = nil
+}
+
+// merge joins the underflowed bucket with its neighbour, or drops it if it is empty.
+func (s *SortedList[T]) merge(bId int) {
+	if len(s.buckets[bId].data) == 0 {
+		s.removeBucket(bId)
+		return
+	}
+	if len(s.buckets) < 2 {
+		return
+	}
+	if bId == len(s.buckets)-1 {
+		bId--
+	}
+	b, next := s.buckets[bId], s.buckets[bId+1]
+	b.data = append(b.data, next.data...)
+	s.removeBucket(bId + 1)
+	if len(b.data) >= 2*s.bsz {
+		s.split(bId)
+	}
+}
+
+func (s *SortedList[T]) removeBucket(bId int) {
+	copy(s.buckets[bId:], s.buckets[bId+1:])
+	s.buckets[len(s.buckets)-1] = nil
+	s.buckets = s.buckets[:len(s.buckets)-1]
+	s.index = nil
+}
+
+func (s *SortedList[T]) buildIndex() {
+	s.index = make([]int, len(s.buckets))
+	for n, b := range s.buckets {
+		s.index[n] += len(b.data)
+		if p := n | (n + 1); p < len(s.index) {
+			s.index[p] += s.index[n]
+		}
+	}
+}
+
+func (s *SortedList[T]) updateIndex(bId, delta int) {
+	if s.index == nil {
+		return
+	}
+	for ; bId < len(s.index); bId |= bId + 1 {
+		s.index[bId] += delta
+	}
+}
+
+// offset returns the count of values stored in the buckets preceding `bId`.
+func (s *SortedList[T]) offset(bId int) int {
+	if s.index == nil {
+		s.buildIndex()
+	}
+	var sum int
+	for bId--; bId >= 0; bId = (bId & (bId + 1)) - 1 {
+		sum += s.index[bId]
+	}
+	return sum
+}
+
+// locate converts the position into the bucket number and the position inside the bucket.
+func (s *SortedList[T]) locate(i int) (bId, xId int) {
+	if s.index == nil {
+		s.buildIndex()
+	}
+	var step = 1
+	for step<<1 <= len(s.index) {
+		step <<= 1
+	}
+	// descend the implicit tree to find the largest prefix with sum <= i
+	var pos int
+	for ; step > 0; step >>= 1 {
+		if next := pos + step; next <= len(s.index) && s.index[next-1] <= i {
+			pos = next
+			i -= s.index[next-1]
+		}
+	}
+	return pos, i
+}
@@ -0,0 +1,174 @@
+package sortedlist
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortedList(t *testing.T) {
+	t.Parallel()
+	t.Run("add_at", func(t *testing.T) {
+		t.Parallel()
+
+		s := New[int](0)
+		for _, v := range []int{5, 3, 9, 1, 7, 3} {
+			s.Add(v)
+		}
+		require.Equal(t, 6, s.Len())
+		for n, expected := range []int{1, 3, 3, 5, 7, 9} {
+			require.Equal(t, expected, s.At(n))
+		}
+	})
+	t.Run("index_of", func(t *testing.T) {
+		t.Parallel()
+
+		s := New[string](4)
+		for _, v := range []string{"foo", "bar", "baz", "qux", "quux", "corge", "grault", "bar"} {
+			s.Add(v)
+		}
+		require.Equal(t, 0, s.IndexOf("bar"))
+		require.Equal(t, 2, s.IndexOf("baz"))
+		require.Equal(t, 7, s.IndexOf("qux"))
+		require.Equal(t, -1, s.IndexOf("waldo"))
+		require.True(t, s.Has("grault"))
+		require.False(t, s.Has("garply"))
+	})
+	t.Run("bisect", func(t *testing.T) {
+		t.Parallel()
+
+		s := New[int](2)
+		for _, v := range []int{10, 20, 20, 20, 30} {
+			s.Add(v)
+		}
+		require.Equal(t, 0, s.Bisect(5))
+		require.Equal(t, 1, s.Bisect(20))
+		require.Equal(t, 4, s.BisectRight(20))
+		require.Equal(t, 5, s.Bisect(35))
+		require.Equal(t, 5, s.BisectRight(30))
+	})
+	t.Run("remove", func(t *testing.T) {
+		t.Parallel()
+
+		s := New[int](2)
+		for n := 0; n < 10; n++ {
+			s.Add(n)
+		}
+		require.True(t, s.Remove(4))
+		require.False(t, s.Remove(4))
+		require.Equal(t, 9, s.Len())
+		require.Equal(t, 5, s.At(4))
+		require.Equal(t, 0, s.Delete(0))
+		require.Equal(t, 1, s.At(0))
+		require.Equal(t, 8, s.Len())
+	})
+	t.Run("comparator", func(t *testing.T) {
+		t.Parallel()
+
+		type Elem struct {
+			k int
+			s string
+		}
+		s := NewFunc[Elem](0, func(a, b *Elem) bool {
+			return a.k > b.k
+		})
+		s.Add(Elem{k: 1, s: "one"})
+		s.Add(Elem{k: 3, s: "three"})
+		s.Add(Elem{k: 2, s: "two"})
+		require.Equal(t, "three", s.At(0).s)
+		require.Equal(t, "two", s.At(1).s)
+		require.Equal(t, "one", s.At(2).s)
+	})
+	t.Run("range", func(t *testing.T) {
+		t.Parallel()
+
+		s := New[int](8)
+		for n := 0; n < 100; n++ {
+			s.Add(n * 2)
+		}
+		var got []int
+		s.Range(15, 31, func(v int) bool {
+			got = append(got, v)
+			return true
+		})
+		require.Equal(t, []int{16, 18, 20, 22, 24, 26, 28, 30}, got)
+
+		got = got[:0]
+		s.Slice(95, 120, func(v int) bool {
+			got = append(got, v)
+			return true
+		})
+		require.Equal(t, []int{190, 192, 194, 196, 198}, got)
+
+		got = got[:0]
+		s.Each(func(v int) bool {
+			got = append(got, v)
+			return len(got) < 3
+		})
+		require.Equal(t, []int{0, 2, 4}, got)
+	})
+}
+
+func TestSortedListRandom(t *testing.T) {
+	t.Parallel()
+	var (
+		rnd      = rand.New(rand.NewSource(42))
+		s        = New[int](16)
+		expected []int
+	)
+	for n := 0; n < 20000; n++ {
+		v := rnd.Intn(5000)
+		if n%3 == 2 {
+			idx := sort.SearchInts(expected, v)
+			found := idx < len(expected) && expected[idx] == v
+			require.Equal(t, found, s.Remove(v))
+			if found {
+				expected = append(expected[:idx], expected[idx+1:]...)
+			}
+			continue
+		}
+		s.Add(v)
+		idx := sort.SearchInts(expected, v+1)
+		expected = append(expected, 0)
+		copy(expected[idx+1:], expected[idx:])
+		expected[idx] = v
+	}
+	require.Equal(t, len(expected), s.Len())
+	for n, v := range expected {
+		require.Equal(t, v, s.At(n))
+	}
+	for n := 0; n < 1000; n++ {
+		v := rnd.Intn(5000)
+		require.Equal(t, sort.SearchInts(expected, v), s.Bisect(v))
+		require.Equal(t, sort.SearchInts(expected, v+1), s.BisectRight(v))
+	}
+	// drain
+	for s.Len() > 0 {
+		s.Delete(rnd.Intn(s.Len()))
+	}
+	require.Len(t, s.buckets, 0)
+}
+
+func BenchmarkSortedList(b *testing.B) {
+	b.Run("Add", func(b *testing.B) {
+		b.ReportAllocs()
+		s := New[int](0)
+		rnd := rand.New(rand.NewSource(1))
+		for n := 0; n < b.N; n++ {
+			s.Add(rnd.Int())
+		}
+	})
+	b.Run("At", func(b *testing.B) {
+		s := New[int](0)
+		for n := 0; n < 1_000_000; n++ {
+			s.Add(n)
+		}
+		b.ResetTimer()
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			_ = s.At(n % 1_000_000)
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package sparseset
+
+// Set is the read-only view of the keys of a set. It is implemented by SparseSet with any payload and by KeySet,
+// so a SparseSet can be intersected in place with a KeySet mask, for example.
+type Set[K Key] interface {
+	Len() int
+	Has(key K) bool
+	EachKey(callback func(key K) bool)
+}
+
+// EachKey iterates through all the keys in the order of the dense array, which takes O(Len).
+// If the callback function returns false, the iteration will be stopped.
+func (s *SparseSet[K, T]) EachKey(callback func(key K) bool) {
+	s.dense.Each(func(br *backRef[K, T]) bool {
+		return callback(br.ref)
+	})
+}
+
+// Union returns a new set with the objects of both sets. If a key is present in both sets, the object of `a` is taken.
+func Union[K Key, T any](a, b *SparseSet[K, T]) *SparseSet[K, T] {
+	c := a.clone()
+	c.UnionWith(b)
+	return c
+}
+
+// Intersect returns a new set with the objects of `a` whose keys are present in `b`, the payload of `b` is not used.
+// The smaller of the two sets is iterated, and the keys are looked up in the sparse array of the other one.
+func Intersect[K Key, T, U any](a *SparseSet[K, T], b *SparseSet[K, U]) *SparseSet[K, T] {
+	c := New[K, T](0, a.bsz)
+	if a.Len() <= b.Len() {
+		a.dense.Each(func(br *backRef[K, T]) bool {
+			if b.Has(br.ref) {
+				c.Set(br.ref, br.data)
+			}
+			return true
+		})
+		return c
+	}
+	b.EachKey(func(key K) bool {
+		if ref := a.Get(key); ref != nil {
+			c.Set(key, *ref)
+		}
+		return true
+	})
+	return c
+}
+
+// Difference returns a new set with the objects of `a` whose keys are not present in `b`,
+// the payload of `b` is not used.
+func Difference[K Key, T, U any](a *SparseSet[K, T], b *SparseSet[K, U]) *SparseSet[K, T] {
+	c := New[K, T](0, a.bsz)
+	a.dense.Each(func(br *backRef[K, T]) bool {
+		if !b.Has(br.ref) {
+			c.Set(br.ref, br.data)
+		}
+		return true
+	})
+	return c
+}
+
+// SymmetricDifference returns a new set with the objects whose keys are present in only one of the sets.
+func SymmetricDifference[K Key, T any](a, b *SparseSet[K, T]) *SparseSet[K, T] {
+	c := a.clone()
+	c.SymmetricDifferenceWith(b)
+	return c
+}
+
+// UnionWith adds the objects of the other set whose keys are not present in this set.
+func (s *SparseSet[K, T]) UnionWith(other *SparseSet[K, T]) {
+	other.dense.Each(func(br *backRef[K, T]) bool {
+		s.LoadOrStore(br.ref, br.data)
+		return true
+	})
+}
+
+// IntersectWith deletes the objects whose keys are not present in the other set.
+func (s *SparseSet[K, T]) IntersectWith(other Set[K]) {
+	s.retain(func(key K) bool {
+		return other.Has(key)
+	})
+}
+
+// DifferenceWith deletes the objects whose keys are present in the other set.
+// The smaller of the two sets is iterated, and the keys are looked up in the other one.
+func (s *SparseSet[K, T]) DifferenceWith(other Set[K]) {
+	if other.Len() < s.Len() {
+		other.EachKey(func(key K) bool {
+			s.TryDelete(key)
+			return true
+		})
+		return
+	}
+	s.retain(func(key K) bool {
+		return !other.Has(key)
+	})
+}
+
+// SymmetricDifferenceWith deletes the objects whose keys are present in the other set
+// and adds the objects of the other set whose keys are not present in this set.
+func (s *SparseSet[K, T]) SymmetricDifferenceWith(other *SparseSet[K, T]) {
+	if other == s {
+		// the dense array would shrink under the iteration
+		s.Clear()
+		return
+	}
+	other.dense.Each(func(br *backRef[K, T]) bool {
+		if !s.TryDelete(br.ref) {
+			s.Set(br.ref, br.data)
+		}
+		return true
+	})
+}
+
+// retain deletes the objects whose keys do not satisfy the predicate. The dense array is walked from the end,
+// so the object moved into the place of the deleted one has already been checked.
+func (s *SparseSet[K, T]) retain(keep func(key K) bool) {
+	for i := s.dense.Len() - 1; i >= 0; i-- {
+		if key := s.dense.Get(i).ref; !keep(key) {
+			s.TryDelete(key)
+		}
+	}
+}
+
+// clone returns a copy of the set with the same order of the dense array.
+func (s *SparseSet[K, T]) clone() *SparseSet[K, T] {
+	c := New[K, T](0, s.bsz)
+	s.dense.Each(func(br *backRef[K, T]) bool {
+		c.Set(br.ref, br.data)
+		return true
+	})
+	return c
+}
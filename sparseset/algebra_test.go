@@ -0,0 +1,132 @@
+package sparseset
+
+import (
+	"sort"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func keysOf[K Key](s Set[K]) []K {
+	var keys []K
+	s.EachKey(func(key K) bool {
+		keys = append(keys, key)
+		return true
+	})
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+func newSet(keys ...int) *SparseSet[int, string] {
+	s := New[int, string](0, 0)
+	for _, key := range keys {
+		s.Set(key, "v")
+	}
+	return s
+}
+
+func TestSetAlgebra(t *testing.T) {
+	t.Parallel()
+	t.Run("new_set", func(t *testing.T) {
+		t.Parallel()
+
+		a := New[int, string](0, 0)
+		a.Set(1, "a1")
+		a.Set(2, "a2")
+		a.Set(3, "a3")
+		b := New[int, string](0, 0)
+		b.Set(3, "b3")
+		b.Set(4, "b4")
+
+		u := Union(a, b)
+		require.Equal(t, []int{1, 2, 3, 4}, keysOf[int](u))
+		require.Equal(t, "a3", *u.Get(3))
+		require.Equal(t, "b4", *u.Get(4))
+
+		i := Intersect(a, b)
+		require.Equal(t, []int{3}, keysOf[int](i))
+		require.Equal(t, "a3", *i.Get(3))
+		require.Equal(t, []int{3}, keysOf[int](Intersect(b, a)))
+
+		require.Equal(t, []int{1, 2}, keysOf[int](Difference(a, b)))
+		require.Equal(t, []int{1, 2, 4}, keysOf[int](SymmetricDifference(a, b)))
+
+		// the arguments stay intact
+		require.Equal(t, []int{1, 2, 3}, keysOf[int](a))
+		require.Equal(t, []int{3, 4}, keysOf[int](b))
+	})
+	t.Run("in_place", func(t *testing.T) {
+		t.Parallel()
+
+		s := newSet(1, 2, 3, 4, 5, 6)
+		s.IntersectWith(newSet(2, 4, 6, 8))
+		require.Equal(t, []int{2, 4, 6}, keysOf[int](s))
+		require.Equal(t, 3, s.Len())
+
+		s.DifferenceWith(newSet(4))
+		require.Equal(t, []int{2, 6}, keysOf[int](s))
+		s.DifferenceWith(newSet(0, 1, 2, 3, 5))
+		require.Equal(t, []int{6}, keysOf[int](s))
+
+		s.UnionWith(newSet(1, 6))
+		require.Equal(t, []int{1, 6}, keysOf[int](s))
+		s.SymmetricDifferenceWith(newSet(6, 7))
+		require.Equal(t, []int{1, 7}, keysOf[int](s))
+	})
+	t.Run("self", func(t *testing.T) {
+		t.Parallel()
+
+		keys := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+		s := newSet(keys...)
+		s.UnionWith(s)
+		require.Equal(t, keys, keysOf[int](s))
+		s.IntersectWith(s)
+		require.Equal(t, keys, keysOf[int](s))
+		s.DifferenceWith(s)
+		require.Empty(t, keysOf[int](s))
+		require.Equal(t, 0, s.Len())
+
+		s = newSet(keys...)
+		s.SymmetricDifferenceWith(s)
+		require.Empty(t, keysOf[int](s))
+		require.Equal(t, 0, s.Len())
+		require.False(t, s.Has(0))
+
+		k := NewKeySet[int](0, 0)
+		for _, key := range keys {
+			k.Add(key)
+		}
+		k.DifferenceWith(k)
+		require.Equal(t, 0, k.Len())
+	})
+	t.Run("key_set", func(t *testing.T) {
+		t.Parallel()
+
+		a := NewKeySet[EntityID](0, 0)
+		require.True(t, a.Add(1))
+		require.False(t, a.Add(1))
+		a.Add(2)
+		a.Add(3)
+		b := NewKeySet[EntityID](0, 0)
+		b.Add(3)
+		b.Add(5)
+
+		require.Equal(t, []EntityID{1, 2, 3, 5}, keysOf[EntityID](a.Union(b)))
+		require.Equal(t, []EntityID{3}, keysOf[EntityID](a.Intersect(b)))
+		require.Equal(t, []EntityID{1, 2}, keysOf[EntityID](a.Difference(b)))
+		require.Equal(t, []EntityID{1, 2, 5}, keysOf[EntityID](a.SymmetricDifference(b)))
+
+		// a payload set masked by a key set
+		s := New[EntityID, string](0, 0)
+		s.Set(2, "two")
+		s.Set(5, "five")
+		require.Equal(t, []EntityID{2}, keysOf[EntityID](Intersect(s, &a.SparseSet)))
+		s.IntersectWith(a)
+		require.Equal(t, []EntityID{2}, keysOf[EntityID](s))
+
+		require.Equal(t, unsafe.Sizeof(EntityID(0)), unsafe.Sizeof(backRef[EntityID, struct{}]{}))
+	})
+}
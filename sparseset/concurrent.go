@@ -0,0 +1,200 @@
+package sparseset
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Concurrent is a SparseSet which is safe for use by multiple goroutines.
+//
+// The keys are partitioned between shards by the pages of the sparse array: every page of keys is owned by exactly
+// one shard, which keeps its own sparse pages and its own dense Collection behind its own RW lock. So the goroutines
+// working with different ranges of keys do not contend, and the readers of a shard do not block each other.
+//
+// The objects are returned by copy, since a reference could not be used safely after the lock is released.
+type Concurrent[K Key, T any] struct {
+	shards []shard[K, T]
+}
+
+type shard[K Key, T any] struct {
+	mu  sync.RWMutex
+	set *SparseSet[K, T]
+	_   [40]byte // avoid false sharing between shards
+}
+
+// NewConcurrent creates a new Concurrent SparseSet with the specified count of shards and the bucket size
+// of their dense Collections. If the count of shards is zero, four shards per P will be used.
+func NewConcurrent[K Key, T any](shards, bsz int) *Concurrent[K, T] {
+	if shards <= 0 {
+		shards = 4 * runtime.GOMAXPROCS(0)
+	}
+	c := Concurrent[K, T]{shards: make([]shard[K, T], shards)}
+	for i := range c.shards {
+		c.shards[i].set = New[K, T](0, bsz)
+	}
+	return &c
+}
+
+// shard returns the shard owning the page of the key and the key local to that shard, or nil if the key is not valid.
+// A shard owns only every n-th page, so the pages are renumbered to keep the sparse array of the shard dense.
+func (c *Concurrent[K, T]) shard(key K) (*shard[K, T], K) {
+	id, ok := index(key)
+	if !ok {
+		return nil, key
+	}
+	pn := id >> pageShift
+	return &c.shards[pn%len(c.shards)], K((pn/len(c.shards))<<pageShift | id&pageMask)
+}
+
+// global converts the key local to the i-th shard back to the key of the set.
+func (c *Concurrent[K, T]) global(i int, local K) K {
+	id := int(local)
+	return K(((id>>pageShift)*len(c.shards)+i)<<pageShift | id&pageMask)
+}
+
+// Len returns the count of objects. Under concurrent modification it is only an estimate.
+func (c *Concurrent[K, T]) Len() int {
+	var size int
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.RLock()
+		size += s.set.Len()
+		s.mu.RUnlock()
+	}
+	return size
+}
+
+// Set stores the object under a specific identifier. It returns false and stores nothing if the key is not valid.
+func (c *Concurrent[K, T]) Set(key K, val T) bool {
+	s, local := c.shard(key)
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	s.set.Set(local, val)
+	s.mu.Unlock()
+	return true
+}
+
+// Get returns a copy of the object associated with the identifier `key`, the second value reports whether
+// the object exists.
+func (c *Concurrent[K, T]) Get(key K) (T, bool) {
+	s, local := c.shard(key)
+	if s == nil {
+		var empty T
+		return empty, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.set.GetOK(local)
+}
+
+// Has returns true if there is an object associated with the identifier `key`.
+func (c *Concurrent[K, T]) Has(key K) bool {
+	s, local := c.shard(key)
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.set.Has(local)
+}
+
+// Delete deletes an object by its Key and returns true if the key was present.
+func (c *Concurrent[K, T]) Delete(key K) bool {
+	s, local := c.shard(key)
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.set.TryDelete(local)
+}
+
+// LoadOrStore returns a copy of the existing object associated with the identifier `key`.
+// Otherwise, it stores and returns the given value. The loaded result is true if the object existed.
+func (c *Concurrent[K, T]) LoadOrStore(key K, val T) (actual T, loaded bool) {
+	s, local := c.shard(key)
+	if s == nil {
+		return val, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ref, loaded := s.set.LoadOrStore(local, val)
+	return *ref, loaded
+}
+
+// Swap stores the value under the identifier `key` and returns the previous value, if any.
+// The loaded result reports whether the key was present.
+func (c *Concurrent[K, T]) Swap(key K, val T) (previous T, loaded bool) {
+	s, local := c.shard(key)
+	if s == nil {
+		return previous, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.set.Swap(local, val)
+}
+
+// Update calls the function `fn` with the current value associated with the identifier `key` and the flag of its
+// presence, and stores the returned value if `keep` is true, otherwise the key is deleted. See SparseSet.Update.
+//
+// The whole read-modify-write runs under the lock of the shard, so it is atomic. The function must not access
+// the Concurrent set. It returns the stored value, the second value is false if the key is deleted or not valid.
+func (c *Concurrent[K, T]) Update(key K, fn func(val T, ok bool) (updated T, keep bool)) (T, bool) {
+	var stored T
+	s, local := c.shard(key)
+	if s == nil {
+		return stored, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ref := s.set.Update(local, fn); ref != nil {
+		return *ref, true
+	}
+	return stored, false
+}
+
+// Each iterates through all the objects shard by shard and calls the provided callback function for each of them.
+// If the callback function returns false, the iteration will be stopped.
+//
+// The objects of a shard are copied under its read lock, so the callback sees a consistent state of each shard,
+// but not of the whole set. The callback is called without holding any lock and may modify the set.
+func (c *Concurrent[K, T]) Each(callback func(key K, val T) bool) {
+	var buf []backRef[K, T]
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.RLock()
+		buf = buf[:0]
+		s.set.dense.Each(func(br *backRef[K, T]) bool {
+			buf = append(buf, *br)
+			return true
+		})
+		s.mu.RUnlock()
+		for n := range buf {
+			if !callback(c.global(i, buf[n].ref), buf[n].data) {
+				return
+			}
+		}
+	}
+}
+
+// Compact releases the unused memory of the shards one by one, see SparseSet.Compact.
+func (c *Concurrent[K, T]) Compact() {
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		s.set.Compact()
+		s.mu.Unlock()
+	}
+}
+
+// Clear deletes all the objects from the set, shard by shard.
+func (c *Concurrent[K, T]) Clear() {
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		s.set.Clear()
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,132 @@
+package sparseset
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrent(t *testing.T) {
+	t.Parallel()
+	t.Run("api", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewConcurrent[EntityID, string](0, 0)
+		require.True(t, c.Set(1, "one"))
+		require.True(t, c.Set(1<<20, "far"))
+		val, ok := c.Get(1)
+		require.True(t, ok)
+		require.Equal(t, "one", val)
+		require.True(t, c.Has(1<<20))
+
+		actual, loaded := c.LoadOrStore(1, "uno")
+		require.True(t, loaded)
+		require.Equal(t, "one", actual)
+		prev, loaded := c.Swap(1, "uno")
+		require.True(t, loaded)
+		require.Equal(t, "one", prev)
+
+		require.True(t, c.Delete(1<<20))
+		require.False(t, c.Delete(1<<20))
+		require.Equal(t, 1, c.Len())
+
+		c.Clear()
+		require.Equal(t, 0, c.Len())
+		require.False(t, NewConcurrent[int, int](1, 0).Set(-1, 0))
+	})
+	t.Run("update", func(t *testing.T) {
+		t.Parallel()
+
+		const (
+			workers = 8
+			rounds  = 1000
+			keys    = 1000
+			step    = 13 // spreads the keys over several pages
+		)
+		var (
+			c  = NewConcurrent[int, int](4, 0)
+			wg sync.WaitGroup
+		)
+		wg.Add(workers)
+		for w := 0; w < workers; w++ {
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < rounds; i++ {
+					c.Update((w*rounds+i)%keys*step, func(val int, ok bool) (int, bool) {
+						return val + 1, true
+					})
+					if i%10 == 0 {
+						c.Each(func(key int, val int) bool {
+							return key < keys*step/2
+						})
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		var sum int
+		c.Each(func(_ int, val int) bool {
+			sum += val
+			return true
+		})
+		require.Equal(t, workers*rounds, sum)
+		require.Equal(t, keys, c.Len())
+
+		val, ok := c.Update(0, func(int, bool) (int, bool) {
+			return 0, false
+		})
+		require.False(t, ok)
+		require.Equal(t, 0, val)
+		require.False(t, c.Has(0))
+	})
+	t.Run("shard_pages", func(t *testing.T) {
+		t.Parallel()
+
+		const (
+			shards = 8
+			pages  = 64
+		)
+		c := NewConcurrent[uint32, int](shards, 0)
+		for pn := 0; pn < pages; pn++ {
+			key := uint32(pn<<pageShift | pn)
+			require.True(t, c.Set(key, pn))
+		}
+		// every shard holds only the pages it owns
+		for i := range c.shards {
+			require.Equal(t, pages/shards, len(c.shards[i].set.sparse.dir))
+			require.Equal(t, pages/shards, c.shards[i].set.Len())
+		}
+		seen := make(map[uint32]int)
+		c.Each(func(key uint32, val int) bool {
+			seen[key] = val
+			return true
+		})
+		require.Len(t, seen, pages)
+		for pn := 0; pn < pages; pn++ {
+			val, ok := c.Get(uint32(pn<<pageShift | pn))
+			require.True(t, ok)
+			require.Equal(t, pn, val)
+			require.Equal(t, pn, seen[uint32(pn<<pageShift|pn)])
+		}
+	})
+}
+
+func BenchmarkConcurrent(b *testing.B) {
+	c := NewConcurrent[int, int](0, 0)
+	for i := 0; i < 1<<16; i++ {
+		c.Set(i, i)
+	}
+	b.RunParallel(func(pb *testing.PB) {
+		var i int
+		for pb.Next() {
+			i = (i + 7919) & (1<<16 - 1)
+			if i%8 == 0 {
+				c.Set(i, i)
+			} else {
+				c.Get(i)
+			}
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package sparseset
+
+import (
+	"sort"
+
+	"github.com/iv-menshenin/fusion/fsort"
+)
+
+// EachDense iterates through all the elements in the order of the dense array and calls the provided callback
+// function for each of them. Unlike Each, it takes O(Len) regardless of the keys and reads the memory sequentially.
+// If the callback function returns false, the iteration will be stopped.
+//
+// The order is the order of insertion until an element is deleted or the set is sorted with SortByKey or SortBy.
+func (s *SparseSet[K, T]) EachDense(callback func(key K, val *T) bool) {
+	s.dense.Each(func(br *backRef[K, T]) bool {
+		return callback(br.ref, &br.data)
+	})
+}
+
+// SortByKey reorders the dense array by the keys, so EachDense visits the elements in the ascending order of keys.
+//
+// Please note that after calling this method, the links that were obtained earlier by the Get method may be invalid.
+func (s *SparseSet[K, T]) SortByKey() {
+	s.sortDense(func(a, b *backRef[K, T]) bool {
+		return a.ref < b.ref
+	})
+}
+
+// SortBy reorders the dense array by the objects using the `less` function, so EachDense visits the elements
+// in that order.
+//
+// Please note that after calling this method, the links that were obtained earlier by the Get method may be invalid.
+func (s *SparseSet[K, T]) SortBy(less func(a, b *T) bool) {
+	s.sortDense(func(a, b *backRef[K, T]) bool {
+		return less(&a.data, &b.data)
+	})
+}
+
+// sortDense sorts the dense array and then points the sparse slots to the new positions.
+func (s *SparseSet[K, T]) sortDense(less func(a, b *backRef[K, T]) bool) {
+	sort.Sort(fsort.Sortable[backRef[K, T]](s.dense, less))
+	var pos int
+	s.dense.Each(func(br *backRef[K, T]) bool {
+		_, slot := s.slot(br.ref)
+		*slot = pos
+		pos++
+		return true
+	})
+}
@@ -0,0 +1,98 @@
+package sparseset
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func denseKeys[K Key, T any](s *SparseSet[K, T]) []K {
+	var keys []K
+	s.EachDense(func(key K, _ *T) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+func TestSparseSetDense(t *testing.T) {
+	t.Parallel()
+	t.Run("each_dense", func(t *testing.T) {
+		t.Parallel()
+
+		sp := New[int, int](0, 0)
+		for _, key := range []int{30, 1 << 30, 10, 20} {
+			sp.Set(key, key*2)
+		}
+		sp.Delete(30)
+		require.Equal(t, []int{20, 1 << 30, 10}, denseKeys(sp))
+
+		var visited int
+		sp.EachDense(func(key int, val *int) bool {
+			require.Equal(t, key*2, *val)
+			visited++
+			return false
+		})
+		require.Equal(t, 1, visited)
+	})
+	t.Run("sort_by_key", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			rnd = rand.New(rand.NewSource(7))
+			sp  = New[int, int](0, 16)
+		)
+		for n := 0; n < 1000; n++ {
+			key := rnd.Intn(100_000)
+			sp.Set(key, -key)
+		}
+		sp.SortByKey()
+		require.Equal(t, sp.Keys(), denseKeys(sp))
+		for _, key := range sp.Keys() {
+			require.Equal(t, -key, *sp.Get(key))
+		}
+	})
+	t.Run("sort_by", func(t *testing.T) {
+		t.Parallel()
+
+		sp := New[int, string](0, 0)
+		sp.Set(1, "c")
+		sp.Set(2, "a")
+		sp.Set(3, "b")
+		sp.SortBy(func(a, b *string) bool {
+			return *a < *b
+		})
+		require.Equal(t, []int{2, 3, 1}, denseKeys(sp))
+		require.Equal(t, "c", *sp.Get(1))
+
+		sp.Delete(2)
+		require.Equal(t, []int{1, 3}, denseKeys(sp))
+		require.Equal(t, "b", *sp.Get(3))
+	})
+}
+
+func BenchmarkSparseSetEachDense(b *testing.B) {
+	sp := New[int, uint64](0, 0)
+	for i := 0; i < 1_000_000; i++ {
+		sp.Set(i*64, uint64(i))
+	}
+	b.Run("each", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			sp.Each(func(_ int, val *uint64) bool {
+				*val++
+				return true
+			})
+		}
+	})
+	b.Run("each_dense", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			sp.EachDense(func(_ int, val *uint64) bool {
+				*val++
+				return true
+			})
+		}
+	})
+}
@@ -0,0 +1,412 @@
+package sparseset
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/iv-menshenin/fusion/errors"
+)
+
+// SyncPolicy defines when Durable flushes the log file to the disk with fsync.
+type SyncPolicy int
+
+const (
+	// SyncAlways calls fsync after every write, so an acknowledged write survives a power loss.
+	SyncAlways SyncPolicy = iota
+	// SyncInterval calls fsync on a background goroutine every sync interval if there were writes since
+	// the previous fsync, so up to the interval of writes can be lost on a power loss, but not on a crash
+	// of the process. A zero interval means SyncAlways.
+	SyncInterval
+	// SyncNever leaves flushing to the operating system. Snapshot and Close still call fsync.
+	SyncNever
+)
+
+// DurableOptions configures Durable. The zero value means SyncAlways and manual snapshots.
+type DurableOptions struct {
+	Sync         SyncPolicy
+	SyncInterval time.Duration
+	// SnapshotEvery is the count of log records after which a snapshot is taken automatically, zero disables it.
+	SnapshotEvery int
+	// BucketSize is the bucket size of the dense Collection, see New.
+	BucketSize int
+}
+
+// Durable is a SparseSet which persists its state in a directory. Every Set and Delete is appended to
+// the write-ahead log before it is applied, and a snapshot of the whole set replaces the log from time to time.
+// On open, the state is recovered by loading the snapshot and replaying the log; a record torn by a crash
+// at the end of the log is discarded.
+//
+// The values are written with the pluggable Codec. Like SparseSet, Durable is not safe for concurrent use.
+// The objects are modified only with Set and Delete: changes made through the references would not be logged.
+type Durable[K Key, T any] struct {
+	set   *SparseSet[K, T]
+	dir   string
+	opts  DurableOptions
+	codec Codec[T]
+
+	log     logFile
+	off     int64 // end of the last record in the log
+	w       recordWriter[T]
+	records int // count of records in the log
+	failed  error
+	closed  bool
+
+	// the background fsync of SyncInterval
+	dirty   int32 // set atomically when the log has writes since the last fsync
+	syncMu  sync.Mutex
+	syncErr error
+	stop    chan struct{}
+	stopped sync.WaitGroup
+}
+
+// logFile is the part of *os.File used for the log.
+type logFile interface {
+	io.Writer
+	Sync() error
+	Truncate(size int64) error
+	Seek(offset int64, whence int) (int64, error)
+	Close() error
+}
+
+const (
+	logName      = "wal.log"
+	snapshotName = "snapshot.dat"
+)
+
+// Open opens the Durable SparseSet stored in the directory, creating the directory if it does not exist,
+// and recovers its state. If the codec is nil, GobCodec is used.
+func Open[K Key, T any](dir string, codec Codec[T], opts DurableOptions) (*Durable[K, T], error) {
+	if codec == nil {
+		codec = GobCodec[T]{}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if opts.Sync == SyncInterval && opts.SyncInterval <= 0 {
+		opts.Sync = SyncAlways
+	}
+	d := Durable[K, T]{
+		set:   New[K, T](0, opts.BucketSize),
+		dir:   dir,
+		opts:  opts,
+		codec: codec,
+		w:     recordWriter[T]{codec: codec},
+		stop:  make(chan struct{}),
+	}
+	if err := d.loadSnapshot(); err != nil {
+		return nil, err
+	}
+	if err := d.replayLog(); err != nil {
+		return nil, err
+	}
+	if opts.Sync == SyncInterval {
+		d.stopped.Add(1)
+		go d.syncLoop()
+	}
+	return &d, nil
+}
+
+func (d *Durable[K, T]) loadSnapshot() error {
+	f, err := os.Open(filepath.Join(d.dir, snapshotName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := newRecordReader[T](f, d.codec)
+	for {
+		op, key, val, err := r.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("load snapshot: %w", err)
+		}
+		if op == opSet {
+			d.set.Set(K(key), val)
+		}
+	}
+}
+
+// replayLog applies the records of the log and opens it for appending. The torn tail of the log is truncated.
+func (d *Durable[K, T]) replayLog() error {
+	f, err := os.OpenFile(filepath.Join(d.dir, logName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	r := newRecordReader[T](f, d.codec)
+	for {
+		op, key, val, err := r.next()
+		if err == io.EOF || err == errTorn {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("replay log: %w", err)
+		}
+		d.apply(op, K(key), val)
+		d.records++
+	}
+	if err = f.Truncate(r.off); err == nil {
+		_, err = f.Seek(r.off, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	d.log = f
+	d.off = r.off
+	return nil
+}
+
+func (d *Durable[K, T]) apply(op byte, key K, val T) {
+	switch op {
+	case opSet:
+		d.set.Set(key, val)
+	case opDelete:
+		d.set.TryDelete(key)
+	}
+}
+
+// Set logs and stores the object under a specific identifier. Invalid keys are rejected without logging.
+func (d *Durable[K, T]) Set(key K, val T) error {
+	id, ok := index(key)
+	if !ok {
+		return fmt.Errorf("invalid key %d", key)
+	}
+	if err := d.append(opSet, uint64(id), &val); err != nil {
+		return err
+	}
+	d.set.Set(key, val)
+	return d.maybeSnapshot()
+}
+
+// Delete logs the deletion and deletes an object by its Key. It returns false if the key was not present,
+// in which case nothing is logged.
+func (d *Durable[K, T]) Delete(key K) (bool, error) {
+	if d.closed {
+		return false, errors.ErrClosed
+	}
+	if !d.set.Has(key) {
+		return false, nil
+	}
+	id, _ := index(key)
+	if err := d.append(opDelete, uint64(id), nil); err != nil {
+		return false, err
+	}
+	d.set.TryDelete(key)
+	return true, d.maybeSnapshot()
+}
+
+// Get returns a reference to the object associated with the identifier `key`. Do not modify the object.
+func (d *Durable[K, T]) Get(key K) *T {
+	return d.set.Get(key)
+}
+
+// GetOK returns a copy of the object associated with the identifier `key`, see SparseSet.GetOK.
+func (d *Durable[K, T]) GetOK(key K) (T, bool) {
+	return d.set.GetOK(key)
+}
+
+// Has returns true if there is an object associated with the identifier `key`.
+func (d *Durable[K, T]) Has(key K) bool {
+	return d.set.Has(key)
+}
+
+func (d *Durable[K, T]) Len() int {
+	return d.set.Len()
+}
+
+// Each iterates through all the objects in the ascending order of keys, see SparseSet.Each. Do not modify the objects.
+func (d *Durable[K, T]) Each(callback func(key K, val *T) bool) {
+	d.set.Each(callback)
+}
+
+// EachDense iterates through all the objects in the order of the dense array, see SparseSet.EachDense.
+// Do not modify the objects.
+func (d *Durable[K, T]) EachDense(callback func(key K, val *T) bool) {
+	d.set.EachDense(callback)
+}
+
+// append writes the record to the log. If the write fails, the partially written record is cut off, so the records
+// appended later are not lost behind a torn one on recovery. If even that fails, the Durable rejects all the writes
+// until a successful Snapshot replaces the log.
+func (d *Durable[K, T]) append(op byte, key uint64, val *T) error {
+	if err := d.writable(); err != nil {
+		return err
+	}
+	rec, err := d.w.encode(op, key, val)
+	if err != nil {
+		return err
+	}
+	if _, err = d.log.Write(rec); err != nil {
+		if rerr := d.rewind(); rerr != nil {
+			d.failed = fmt.Errorf("log is damaged: %w", rerr)
+		}
+		return err
+	}
+	d.off += int64(len(rec))
+	d.records++
+	switch d.opts.Sync {
+	case SyncAlways:
+		return d.log.Sync()
+	case SyncInterval:
+		atomic.StoreInt32(&d.dirty, 1)
+	}
+	return nil
+}
+
+// writable returns the error which prevents writing to the log, if any.
+func (d *Durable[K, T]) writable() error {
+	if d.closed {
+		return errors.ErrClosed
+	}
+	if d.failed != nil {
+		return d.failed
+	}
+	d.syncMu.Lock()
+	defer d.syncMu.Unlock()
+	return d.syncErr
+}
+
+// rewind cuts the log off at the end of the last record written successfully.
+func (d *Durable[K, T]) rewind() error {
+	if err := d.log.Truncate(d.off); err != nil {
+		return err
+	}
+	_, err := d.log.Seek(d.off, io.SeekStart)
+	return err
+}
+
+// syncLoop calls fsync every sync interval if the log was written since the previous call.
+// The error of fsync is reported by the next write.
+func (d *Durable[K, T]) syncLoop() {
+	defer d.stopped.Done()
+	ticker := time.NewTicker(d.opts.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-ticker.C:
+			if !atomic.CompareAndSwapInt32(&d.dirty, 1, 0) {
+				continue
+			}
+			if err := d.log.Sync(); err != nil {
+				d.syncMu.Lock()
+				d.syncErr = err
+				d.syncMu.Unlock()
+			}
+		}
+	}
+}
+
+func (d *Durable[K, T]) maybeSnapshot() error {
+	if d.opts.SnapshotEvery > 0 && d.records >= d.opts.SnapshotEvery {
+		return d.Snapshot()
+	}
+	return nil
+}
+
+// Snapshot writes the whole set to a new snapshot file and truncates the log.
+//
+// The snapshot is written to a temporary file which then replaces the previous snapshot, so a crash at any moment
+// leaves either the old snapshot with the full log or the new one. Replaying the log over the new snapshot is
+// harmless, as the records only repeat the state it already contains.
+func (d *Durable[K, T]) Snapshot() error {
+	if d.closed {
+		return errors.ErrClosed
+	}
+	tmp := filepath.Join(d.dir, snapshotName+".tmp")
+	if err := d.writeSnapshot(tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(d.dir, snapshotName)); err != nil {
+		return err
+	}
+	if err := syncDir(d.dir); err != nil {
+		return err
+	}
+	if err := d.log.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := d.log.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	d.off = 0
+	d.records = 0
+	if err := d.log.Sync(); err != nil {
+		return err
+	}
+	// the new snapshot holds the whole state, so the damaged or unsynced log does not matter any more
+	d.failed = nil
+	d.syncMu.Lock()
+	d.syncErr = nil
+	d.syncMu.Unlock()
+	return nil
+}
+
+func (d *Durable[K, T]) writeSnapshot(name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	w := recordWriter[T]{codec: d.codec}
+	d.set.EachDense(func(key K, val *T) bool {
+		var rec []byte
+		if rec, err = w.encode(opSet, uint64(key), val); err == nil {
+			_, err = f.Write(rec)
+		}
+		return err == nil
+	})
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Sync flushes the log file to the disk.
+func (d *Durable[K, T]) Sync() error {
+	if d.closed {
+		return errors.ErrClosed
+	}
+	return d.log.Sync()
+}
+
+// Close flushes and closes the log file. The in-memory state is kept available for reading.
+func (d *Durable[K, T]) Close() error {
+	if d.closed {
+		return errors.ErrClosed
+	}
+	d.closed = true
+	close(d.stop)
+	d.stopped.Wait()
+	err := d.log.Sync()
+	if cerr := d.log.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = f.Sync()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
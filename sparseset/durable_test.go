@@ -0,0 +1,227 @@
+package sparseset
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iv-menshenin/fusion/errors"
+)
+
+type stringCodec struct{}
+
+func (stringCodec) Encode(w io.Writer, val string) error {
+	_, err := io.WriteString(w, val)
+	return err
+}
+
+func (stringCodec) Decode(r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	return string(data), err
+}
+
+var errWrite = fmt.Errorf("write failed")
+
+// failingLog writes only a half of the record on the write with the given number and fails.
+type failingLog struct {
+	logFile
+	failAt   int
+	writes   int
+	truncate error
+}
+
+func (f *failingLog) Write(p []byte) (int, error) {
+	f.writes++
+	if f.writes != f.failAt {
+		return f.logFile.Write(p)
+	}
+	n, _ := f.logFile.Write(p[:len(p)/2])
+	return n, errWrite
+}
+
+func (f *failingLog) Truncate(size int64) error {
+	if f.truncate != nil {
+		return f.truncate
+	}
+	return f.logFile.Truncate(size)
+}
+
+type session struct {
+	User  string
+	Score int
+}
+
+func TestDurable(t *testing.T) {
+	t.Parallel()
+	t.Run("recover", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		d, err := Open[EntityID, session](dir, nil, DurableOptions{})
+		require.NoError(t, err)
+		require.NoError(t, d.Set(1, session{User: "alice", Score: 1}))
+		require.NoError(t, d.Set(2, session{User: "bob", Score: 2}))
+		require.NoError(t, d.Set(1<<20, session{User: "carol", Score: 3}))
+		require.NoError(t, d.Set(1, session{User: "alice", Score: 10}))
+		deleted, err := d.Delete(2)
+		require.NoError(t, err)
+		require.True(t, deleted)
+		deleted, err = d.Delete(2)
+		require.NoError(t, err)
+		require.False(t, deleted)
+		require.NoError(t, d.Close())
+
+		d, err = Open[EntityID, session](dir, nil, DurableOptions{})
+		require.NoError(t, err)
+		defer d.Close()
+		require.Equal(t, 2, d.Len())
+		require.Equal(t, session{User: "alice", Score: 10}, *d.Get(1))
+		require.False(t, d.Has(2))
+		require.Equal(t, "carol", d.Get(1<<20).User)
+	})
+	t.Run("snapshot", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			dir  = t.TempDir()
+			opts = DurableOptions{Sync: SyncNever, SnapshotEvery: 100}
+		)
+		d, err := Open[int, string](dir, stringCodec{}, opts)
+		require.NoError(t, err)
+		for i := 0; i < 1000; i++ {
+			require.NoError(t, d.Set(i%300, "v"+string(rune('a'+i%26))))
+			if i%7 == 0 {
+				_, err = d.Delete(i % 300)
+				require.NoError(t, err)
+			}
+		}
+		expected := make(map[int]string)
+		d.Each(func(key int, val *string) bool {
+			expected[key] = *val
+			return true
+		})
+		require.Less(t, d.records, 100)
+		require.NoError(t, d.Close())
+		_, err = os.Stat(filepath.Join(dir, snapshotName))
+		require.NoError(t, err)
+
+		d, err = Open[int, string](dir, stringCodec{}, opts)
+		require.NoError(t, err)
+		defer d.Close()
+		actual := make(map[int]string)
+		d.Each(func(key int, val *string) bool {
+			actual[key] = *val
+			return true
+		})
+		require.Equal(t, expected, actual)
+	})
+	t.Run("torn_tail", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		d, err := Open[int, string](dir, stringCodec{}, DurableOptions{Sync: SyncInterval})
+		require.NoError(t, err)
+		require.NoError(t, d.Set(1, "one"))
+		require.NoError(t, d.Set(2, "two"))
+		require.NoError(t, d.Close())
+
+		// a crash in the middle of writing the last record
+		name := filepath.Join(dir, logName)
+		info, err := os.Stat(name)
+		require.NoError(t, err)
+		require.NoError(t, os.Truncate(name, info.Size()-2))
+
+		d, err = Open[int, string](dir, stringCodec{}, DurableOptions{})
+		require.NoError(t, err)
+		require.Equal(t, []int{1}, d.set.Keys())
+		require.NoError(t, d.Set(3, "three"))
+		require.NoError(t, d.Close())
+
+		d, err = Open[int, string](dir, stringCodec{}, DurableOptions{})
+		require.NoError(t, err)
+		defer d.Close()
+		require.Equal(t, []int{1, 3}, d.set.Keys())
+		require.Equal(t, "three", *d.Get(3))
+	})
+	t.Run("failed_write", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		d, err := Open[int, string](dir, stringCodec{}, DurableOptions{})
+		require.NoError(t, err)
+		require.NoError(t, d.Set(1, "one"))
+		d.log = &failingLog{logFile: d.log, failAt: 1}
+		require.ErrorIs(t, d.Set(2, "two"), errWrite)
+		require.False(t, d.Has(2))
+		require.NoError(t, d.Set(3, "three"))
+		require.NoError(t, d.Close())
+
+		// the torn record was cut off, so the later write survives
+		d, err = Open[int, string](dir, stringCodec{}, DurableOptions{})
+		require.NoError(t, err)
+		defer d.Close()
+		require.Equal(t, []int{1, 3}, d.set.Keys())
+	})
+	t.Run("damaged_log", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		d, err := Open[int, string](dir, stringCodec{}, DurableOptions{})
+		require.NoError(t, err)
+		require.NoError(t, d.Set(1, "one"))
+		log := d.log
+		d.log = &failingLog{logFile: log, failAt: 1, truncate: errWrite}
+		require.ErrorIs(t, d.Set(2, "two"), errWrite)
+		// the torn record could not be cut off, the later writes would be lost behind it
+		require.ErrorIs(t, d.Set(3, "three"), errWrite)
+		_, err = d.Delete(1)
+		require.ErrorIs(t, err, errWrite)
+
+		d.log = log
+		require.NoError(t, d.Snapshot())
+		require.NoError(t, d.Set(3, "three"))
+		require.NoError(t, d.Close())
+
+		d, err = Open[int, string](dir, stringCodec{}, DurableOptions{})
+		require.NoError(t, err)
+		defer d.Close()
+		require.Equal(t, []int{1, 3}, d.set.Keys())
+	})
+	t.Run("sync_interval", func(t *testing.T) {
+		t.Parallel()
+
+		d, err := Open[int, string](t.TempDir(), stringCodec{}, DurableOptions{
+			Sync:         SyncInterval,
+			SyncInterval: time.Millisecond,
+		})
+		require.NoError(t, err)
+		require.NoError(t, d.Set(1, "one"))
+		// the tail is synced without waiting for the next write
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt32(&d.dirty) == 0
+		}, time.Second, time.Millisecond)
+		require.NoError(t, d.Close())
+	})
+	t.Run("closed", func(t *testing.T) {
+		t.Parallel()
+
+		d, err := Open[int, string](t.TempDir(), nil, DurableOptions{})
+		require.NoError(t, err)
+		require.Error(t, d.Set(-1, "invalid"))
+		require.NoError(t, d.Set(1, "one"))
+		require.NoError(t, d.Close())
+
+		require.ErrorIs(t, d.Set(2, "two"), errors.ErrClosed)
+		_, err = d.Delete(1)
+		require.ErrorIs(t, err, errors.ErrClosed)
+		require.ErrorIs(t, d.Snapshot(), errors.ErrClosed)
+		require.ErrorIs(t, d.Close(), errors.ErrClosed)
+		require.Equal(t, "one", *d.Get(1))
+	})
+}
@@ -18,18 +18,21 @@ type Pair[K Key, T any] struct {
 
 func (s *SparseSet[K, T]) sendElements(ctx context.Context, ch chan<- Pair[K, T]) {
 	defer close(ch)
-	for _, v := range s.sparse {
-		if v == NULL {
-			continue
+	s.sparse.each(func(_ int, pg *page) bool {
+		for _, v := range pg.slots {
+			if v == NULL {
+				continue
+			}
+			val := s.dense.Get(v)
+			select {
+			case <-ctx.Done():
+				return false
+			case ch <- Pair[K, T]{Key: val.ref, Val: &val.data}:
+				// go ahead
+			}
 		}
-		val := s.dense.Get(v)
-		select {
-		case <-ctx.Done():
-			return
-		case ch <- Pair[K, T]{Key: val.ref, Val: &val.data}:
-			// go ahead
-		}
-	}
+		return true
+	})
 }
 
 // Fetcher allows for a sequential traversal of all elements in the SparseSet.
@@ -49,23 +52,22 @@ func (s *SparseSet[K, T]) Fetcher() *Fetcher[K, T] {
 // Fetcher allows for a sequential traversal of all elements in the SparseSet.
 type Fetcher[K Key, T any] struct {
 	s *SparseSet[K, T]
-	i int
+	i int // the next key to look for
 	x int
 }
 
 // Next advances the cursor forward, returning true if the end has not yet been reached; otherwise, it returns false.
 func (f *Fetcher[K, T]) Next() bool {
-	for {
-		f.i++
-		if f.s.Len() < f.i {
-			return false
-		}
-		idx := f.s.sparse[f.i-1]
-		if idx != NULL {
-			f.x = idx
-			return true
-		}
+	if f.i < 0 {
+		return false
+	}
+	key, pos, ok := f.s.sparse.next(f.i)
+	if !ok {
+		f.i = NULL
+		return false
 	}
+	f.i, f.x = key+1, pos
+	return true
 }
 
 // Fetch allows access to the current element and it's key of the SparseSet.
@@ -0,0 +1,37 @@
+package sparseset
+
+// KeySet is a SparseSet of keys without payload, e.g. an entity mask. The dense array contains only the keys.
+type KeySet[K Key] struct {
+	SparseSet[K, struct{}]
+}
+
+// NewKeySet creates a new KeySet, see New for the meaning of the arguments.
+func NewKeySet[K Key](p, bsz int) *KeySet[K] {
+	return &KeySet[K]{SparseSet: *New[K, struct{}](p, bsz)}
+}
+
+// Add adds the key to the set and returns true if it was not present.
+func (s *KeySet[K]) Add(key K) bool {
+	ref, loaded := s.LoadOrStore(key, struct{}{})
+	return ref != nil && !loaded
+}
+
+// Union returns a new KeySet with the keys present in any of the sets.
+func (s *KeySet[K]) Union(other *KeySet[K]) *KeySet[K] {
+	return &KeySet[K]{SparseSet: *Union(&s.SparseSet, &other.SparseSet)}
+}
+
+// Intersect returns a new KeySet with the keys present in both sets.
+func (s *KeySet[K]) Intersect(other *KeySet[K]) *KeySet[K] {
+	return &KeySet[K]{SparseSet: *Intersect(&s.SparseSet, &other.SparseSet)}
+}
+
+// Difference returns a new KeySet with the keys of this set which are not present in the other one.
+func (s *KeySet[K]) Difference(other *KeySet[K]) *KeySet[K] {
+	return &KeySet[K]{SparseSet: *Difference(&s.SparseSet, &other.SparseSet)}
+}
+
+// SymmetricDifference returns a new KeySet with the keys present in only one of the sets.
+func (s *KeySet[K]) SymmetricDifference(other *KeySet[K]) *KeySet[K] {
+	return &KeySet[K]{SparseSet: *SymmetricDifference(&s.SparseSet, &other.SparseSet)}
+}
@@ -0,0 +1,179 @@
+package sparseset
+
+import "sort"
+
+const (
+	pageShift = 12
+	pageSz    = 1 << pageShift
+	pageMask  = pageSz - 1
+
+	// pages with higher numbers are kept in the map instead of the directory,
+	// so a single huge key does not allocate a huge directory
+	maxDirPages = 1 << 16
+)
+
+// page is a fixed-size part of the sparse array, the slots contain positions in the dense array or NULL.
+type page struct {
+	used  int // count of slots which are not NULL
+	slots [pageSz]int
+}
+
+// pages is the sparse array split into lazily allocated pages. Empty ranges of keys do not have pages at all.
+type pages struct {
+	dir     []*page
+	far     map[int]*page
+	farKeys []int // sorted numbers of the far pages
+}
+
+func newPages(p int) pages {
+	n := (p + pageMask) >> pageShift
+	if n > maxDirPages {
+		n = maxDirPages
+	}
+	return pages{dir: make([]*page, 0, n)}
+}
+
+func newPage() *page {
+	var pg page
+	for n := range pg.slots {
+		pg.slots[n] = NULL
+	}
+	return &pg
+}
+
+// lookup returns the page containing the slot of `id`, or nil if it is not allocated.
+func (p *pages) lookup(id int) *page {
+	pn := id >> pageShift
+	if pn < maxDirPages {
+		if pn < len(p.dir) {
+			return p.dir[pn]
+		}
+		return nil
+	}
+	return p.far[pn]
+}
+
+// alloc returns the page containing the slot of `id`, allocating it if necessary.
+func (p *pages) alloc(id int) *page {
+	if pg := p.lookup(id); pg != nil {
+		return pg
+	}
+	pg := newPage()
+	pn := id >> pageShift
+	if pn < maxDirPages {
+		if pn >= len(p.dir) {
+			sz := len(p.dir) * 2
+			if sz <= pn {
+				sz = pn + 1
+			}
+			if sz > maxDirPages {
+				sz = maxDirPages
+			}
+			if sz > cap(p.dir) {
+				dir := make([]*page, len(p.dir), sz)
+				copy(dir, p.dir)
+				p.dir = dir
+			}
+			p.dir = p.dir[:sz]
+		}
+		p.dir[pn] = pg
+		return pg
+	}
+	if p.far == nil {
+		p.far = make(map[int]*page)
+	}
+	p.far[pn] = pg
+	i := sort.SearchInts(p.farKeys, pn)
+	p.farKeys = append(p.farKeys, 0)
+	copy(p.farKeys[i+1:], p.farKeys[i:])
+	p.farKeys[i] = pn
+	return pg
+}
+
+// each calls the callback function for each allocated page in the order of keys, `base` is the key of the first slot.
+func (p *pages) each(callback func(base int, pg *page) bool) {
+	for pn, pg := range p.dir {
+		if pg == nil || pg.used == 0 {
+			continue
+		}
+		if !callback(pn<<pageShift, pg) {
+			return
+		}
+	}
+	for _, pn := range p.farKeys {
+		if pg := p.far[pn]; pg.used > 0 && !callback(pn<<pageShift, pg) {
+			return
+		}
+	}
+}
+
+// next returns the first key starting from `id` which is present, and its position in the dense array.
+func (p *pages) next(id int) (key, pos int, ok bool) {
+	for pn := id >> pageShift; pn < len(p.dir); pn++ {
+		if key, pos, ok = scan(p.dir[pn], pn, id); ok {
+			return key, pos, ok
+		}
+	}
+	for i := sort.SearchInts(p.farKeys, id>>pageShift); i < len(p.farKeys); i++ {
+		pn := p.farKeys[i]
+		if key, pos, ok = scan(p.far[pn], pn, id); ok {
+			return key, pos, ok
+		}
+	}
+	return 0, NULL, false
+}
+
+// scan looks for the first present key of the page number `pn` which is not less than `id`.
+func scan(pg *page, pn, id int) (key, pos int, ok bool) {
+	if pg == nil || pg.used == 0 {
+		return 0, NULL, false
+	}
+	var x int
+	if pn == id>>pageShift {
+		x = id & pageMask
+	}
+	for ; x < pageSz; x++ {
+		if pos = pg.slots[x]; pos != NULL {
+			return pn<<pageShift | x, pos, true
+		}
+	}
+	return 0, NULL, false
+}
+
+// compact releases the pages which have no keys and shrinks the directory to the highest allocated page.
+func (p *pages) compact() {
+	var top int
+	for pn, pg := range p.dir {
+		if pg == nil {
+			continue
+		}
+		if pg.used == 0 {
+			p.dir[pn] = nil
+			continue
+		}
+		top = pn + 1
+	}
+	if top < cap(p.dir) {
+		dir := make([]*page, top)
+		copy(dir, p.dir)
+		p.dir = dir
+	}
+	if len(p.farKeys) == 0 {
+		return
+	}
+	// the map does not shrink on deletion, so the live pages are moved to a new one
+	var (
+		far  = make(map[int]*page)
+		keys []int
+	)
+	for _, pn := range p.farKeys {
+		if pg := p.far[pn]; pg.used > 0 {
+			far[pn] = pg
+			keys = append(keys, pn)
+		}
+	}
+	p.far, p.farKeys = far, keys
+	if len(keys) == 0 {
+		p.far = nil
+	}
+}
@@ -0,0 +1,139 @@
+package sparseset
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type EntityID uint32
+
+func TestSparseSetPages(t *testing.T) {
+	t.Parallel()
+	t.Run("huge_keys", func(t *testing.T) {
+		t.Parallel()
+
+		sp := New[uint64, string](0, 0)
+		keys := []uint64{1 << 40, 7, math.MaxInt64, 1<<40 + 1, pageSz * maxDirPages, 1 << 20}
+		for _, key := range keys {
+			require.NotNil(t, sp.Set(key, "v"))
+		}
+		require.Equal(t, len(keys), sp.Len())
+		require.LessOrEqual(t, len(sp.sparse.dir), maxDirPages)
+		require.Len(t, sp.sparse.farKeys, 3)
+
+		var got []uint64
+		sp.Each(func(key uint64, _ *string) bool {
+			got = append(got, key)
+			return true
+		})
+		require.Equal(t, []uint64{7, 1 << 20, pageSz * maxDirPages, 1 << 40, 1<<40 + 1, math.MaxInt64}, got)
+
+		sp.Delete(1 << 40)
+		require.Nil(t, sp.Get(1<<40))
+		require.Equal(t, "v", *sp.Get(1<<40 + 1))
+	})
+	t.Run("invalid_keys", func(t *testing.T) {
+		t.Parallel()
+
+		sp := New[int, string](0, 0)
+		require.Nil(t, sp.Set(-1, "negative"))
+		require.Nil(t, sp.Get(-1))
+		require.Equal(t, 0, sp.Len())
+
+		su := New[uint64, string](0, 0)
+		require.Nil(t, su.Set(math.MaxUint64, "overflow"))
+		require.Equal(t, 0, su.Len())
+	})
+	t.Run("named_keys", func(t *testing.T) {
+		t.Parallel()
+
+		sp := New[EntityID, int](0, 0)
+		sp.Set(EntityID(3), 3)
+		sp.Set(EntityID(100_000), 100_000)
+		require.Equal(t, 3, *sp.Get(3))
+
+		ref := sp.Set(EntityID(3), 4)
+		require.NotNil(t, ref)
+		require.Equal(t, 4, *ref)
+	})
+	t.Run("fetcher", func(t *testing.T) {
+		t.Parallel()
+
+		sp := New[int, int](0, 0)
+		keys := []int{5, pageSz - 1, pageSz, 3 * pageSz, 1 << 30}
+		for _, key := range keys {
+			sp.Set(key, -key)
+		}
+		var got []int
+		for f := sp.Fetcher(); f.Next(); {
+			key, val := f.Fetch()
+			require.Equal(t, -key, *val)
+			got = append(got, key)
+		}
+		require.Equal(t, keys, got)
+	})
+}
+
+func TestSparseSetCompact(t *testing.T) {
+	t.Parallel()
+	t.Run("clear", func(t *testing.T) {
+		t.Parallel()
+
+		sp := New[int, int](0, 16)
+		for i := 0; i < 10*pageSz; i += 7 {
+			sp.Set(i, i)
+		}
+		sp.Set(1<<29, 1)
+		pages := len(sp.sparse.dir)
+		sp.Clear()
+		require.Equal(t, 0, sp.Len())
+		require.Len(t, sp.sparse.dir, pages)
+		sp.sparse.each(func(int, *page) bool {
+			t.Error("no pages expected")
+			return false
+		})
+		for i := 0; i < 10*pageSz; i++ {
+			require.False(t, sp.Has(i))
+		}
+		require.False(t, sp.Has(1<<29))
+
+		sp.Set(7, 7)
+		require.Equal(t, []int{7}, sp.Keys())
+	})
+	t.Run("compact", func(t *testing.T) {
+		t.Parallel()
+
+		sp := New[int, int](0, 16)
+		for i := 0; i < 100*pageSz; i += 100 {
+			sp.Set(i, i)
+		}
+		sp.Set(1<<29, 1)
+		sp.Set(1<<30, 2)
+		for i := 0; i < 100*pageSz; i += 100 {
+			if i >= 2*pageSz {
+				sp.Delete(i)
+			}
+		}
+		sp.Delete(1 << 29)
+		sp.Compact()
+
+		require.Len(t, sp.sparse.dir, 2)
+		require.Equal(t, []int{1 << 30 >> pageShift}, sp.sparse.farKeys)
+		require.Len(t, sp.sparse.far, 1)
+		require.Equal(t, sp.dense.Len(), sp.Len())
+		for i := 0; i < 2*pageSz; i += 100 {
+			require.Equal(t, i, *sp.Get(i))
+		}
+		require.Equal(t, 2, *sp.Get(1 << 30))
+
+		sp.Delete(1 << 30)
+		sp.Compact()
+		require.Nil(t, sp.sparse.far)
+		require.Empty(t, sp.sparse.farKeys)
+
+		sp.Set(50*pageSz, 1)
+		require.Equal(t, 1, *sp.Get(50 * pageSz))
+	})
+}
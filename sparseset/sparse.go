@@ -4,31 +4,37 @@ import (
 	"github.com/iv-menshenin/fusion/collection"
 )
 
+// Key is the constraint of the SparseSet keys, named integer types like `type EntityID uint32` are allowed.
+// Negative keys, and unsigned keys that do not fit into int, are not valid.
 type Key interface {
-	int | int64 | uint64
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
 }
 
 // SparseSet is designed to save memory and improve performance when dealing with large datasets
 // that are mostly empty. Instead of allocating space for every possible element, it only stores
 // the elements that are present.
+//
+// The sparse array is split into fixed-size pages which are allocated on the first use,
+// so the memory depends on the ranges of keys in use rather than on the max key.
 type SparseSet[K Key, T any] struct {
-	sparse []int
+	sparse pages
 	dense  *collection.Collection[backRef[K, T]]
 	size   int
+	bsz    int
 }
 
 type backRef[K Key, T any] struct {
+	data T // goes first, so the empty payload of KeySet does not add padding
 	ref  K
-	data T
 }
 
+// New creates a new SparseSet. The `p` value is the expected max key, it presizes the page directory,
+// `bsz` is the bucket size of the dense Collection.
 func New[K Key, T any](p, bsz int) *SparseSet[K, T] {
 	s := SparseSet[K, T]{
-		sparse: make([]int, p),
+		sparse: newPages(p),
 		dense:  collection.New[backRef[K, T]](bsz),
-	}
-	for n := 0; n < len(s.sparse); n++ {
-		s.sparse[n] = NULL
+		bsz:    bsz,
 	}
 	return &s
 }
@@ -39,91 +45,206 @@ func (s *SparseSet[K, T]) Len() int {
 
 const NULL = -1
 
-// Set stores the object under a specific identifier, returning a reference to it.
-func (s *SparseSet[K, T]) Set(key K, val T) (ref *T) {
+// index converts the key to the index of the sparse array, the second value is false if the key is not valid.
+func index[K Key](key K) (int, bool) {
 	id := int(key)
-	if currSz := len(s.sparse); currSz <= id {
-		newSize := len(s.sparse) * 2
-		if newSize <= id {
-			newSize = id + 1
-		}
-		old := s.sparse
-		s.sparse = make([]int, 0, newSize)
-		s.sparse = append(s.sparse, old...)
-		s.sparse = s.sparse[:newSize]
-		for n := currSz; n < newSize; n++ {
-			s.sparse[n] = NULL
-		}
+	if id < 0 || K(id) != key {
+		return 0, false
 	}
+	return id, true
+}
 
-	if densePos := s.sparse[id]; densePos == NULL {
-		s.sparse[id] = s.dense.Len()
-		br := s.dense.Push(backRef[K, T]{ref: key, data: val})
-		ref = &br.data
-		s.size++
-	} else {
-		br := s.dense.Get(densePos)
+// Set stores the object under a specific identifier, returning a reference to it.
+// It returns nil and stores nothing if the key is not valid.
+func (s *SparseSet[K, T]) Set(key K, val T) (ref *T) {
+	id, ok := index(key)
+	if !ok {
+		return nil
+	}
+	pg := s.sparse.alloc(id)
+	slot := &pg.slots[id&pageMask]
+	if *slot != NULL {
+		br := s.dense.Get(*slot)
 		br.data = val
+		return &br.data
 	}
-	return ref
+	*slot = s.dense.Len()
+	pg.used++
+	s.size++
+	br := s.dense.Push(backRef[K, T]{ref: key, data: val})
+	return &br.data
+}
+
+// slot returns the slot of the sparse array for the key, or nil if the key is not valid or its page is not allocated.
+func (s *SparseSet[K, T]) slot(key K) (*page, *int) {
+	id, ok := index(key)
+	if !ok {
+		return nil, nil
+	}
+	pg := s.sparse.lookup(id)
+	if pg == nil {
+		return nil, nil
+	}
+	return pg, &pg.slots[id&pageMask]
 }
 
 // Get returns a reference to the object associated with the identifier `key`.
 //
 // The reference data can be modified, but avoid saving the reference, it may become invalid after calling methods that modify it, such as Delete.
 func (s *SparseSet[K, T]) Get(key K) *T {
-	id := int(key)
-	if len(s.sparse) <= id {
+	_, slot := s.slot(key)
+	if slot == nil || *slot == NULL {
 		return nil
 	}
-	if s.sparse[id] == NULL {
+	br := s.dense.Get(*slot)
+	return &br.data
+}
+
+// Has returns true if there is an object associated with the identifier `key`.
+func (s *SparseSet[K, T]) Has(key K) bool {
+	_, slot := s.slot(key)
+	return slot != nil && *slot != NULL
+}
+
+// GetOK returns a copy of the object associated with the identifier `key`, the second value reports whether
+// the object exists, like the two-value map index expression.
+func (s *SparseSet[K, T]) GetOK(key K) (T, bool) {
+	if ref := s.Get(key); ref != nil {
+		return *ref, true
+	}
+	var empty T
+	return empty, false
+}
+
+// LoadOrStore returns a reference to the existing object associated with the identifier `key`.
+// Otherwise, it stores the given value and returns a reference to it. The loaded result is true if the object existed.
+// The returned reference is nil if the key is not valid.
+func (s *SparseSet[K, T]) LoadOrStore(key K, val T) (ref *T, loaded bool) {
+	if ref = s.Get(key); ref != nil {
+		return ref, true
+	}
+	return s.Set(key, val), false
+}
+
+// Swap stores the value under the identifier `key` and returns the previous value, if any.
+// The loaded result reports whether the key was present.
+func (s *SparseSet[K, T]) Swap(key K, val T) (previous T, loaded bool) {
+	if ref := s.Get(key); ref != nil {
+		previous, *ref = *ref, val
+		return previous, true
+	}
+	s.Set(key, val)
+	return previous, false
+}
+
+// Update calls the function `fn` with the current value associated with the identifier `key` and the flag
+// of its presence. The returned value is stored if `keep` is true, otherwise the key is deleted.
+// It returns a reference to the stored object, or nil if the key is deleted or not valid.
+func (s *SparseSet[K, T]) Update(key K, fn func(val T, ok bool) (updated T, keep bool)) *T {
+	old, ok := s.GetOK(key)
+	val, keep := fn(old, ok)
+	if !keep {
+		s.TryDelete(key)
 		return nil
 	}
-	br := s.dense.Get(s.sparse[id])
-	return &br.data
+	return s.Set(key, val)
 }
 
-// Delete deletes an object by its Key from the SparseSet.
+// Delete deletes an object by its Key from the SparseSet. It does nothing if the key is not present.
 //
 // Note that to improve performance, there is a side effect: the deleted object is replaced by the last object,
 // not the next in line. This avoids large data movement when deleting values.
 //
 // Please note that after calling this method, the links that were obtained earlier by the Get method may be invalid.
 func (s *SparseSet[K, T]) Delete(key K) {
-	id := int(key)
-	if len(s.sparse) <= id {
-		panic("out of bounds")
+	s.TryDelete(key)
+}
+
+// TryDelete deletes an object by its Key from the SparseSet and returns true if the key was present.
+// See Delete for the details.
+func (s *SparseSet[K, T]) TryDelete(key K) bool {
+	pg, slot := s.slot(key)
+	if slot == nil || *slot == NULL {
+		return false
 	}
 	s.size--
-	deleted := s.sparse[id]
-	s.sparse[id] = NULL
+	deleted := *slot
+	*slot = NULL
+	pg.used--
 	dd := s.dense.Get(deleted)
 	ld := s.dense.Pop() // removed last from dense
 	if ld.ref == key {
-		return
+		return true
 	}
 	*dd = ld
-	s.sparse[id] = NULL
-	s.sparse[int(ld.ref)] = deleted // ld.ref referenced to entity was poped from dense
+	_, moved := s.slot(ld.ref)
+	*moved = deleted // ld.ref referenced to entity was poped from dense
 	// sparse:     [1|2|3|4|5|6]
 	// dense:      [a|b|c|d|e|f]
 	// remove      -----^
 	// pop it      -----------^  // ld := s.dense.Pop()
 	// place here  -----^        // *dd = ld
-	// swap sparse [1|2|-|4|5|3] // s.sparse[id] = NULL
-	// dense:      [a|b|f|d|e]   // s.sparse[int(ld.ref)] = deleted
+	// swap sparse [1|2|-|4|5|3] // *slot = NULL
+	// dense:      [a|b|f|d|e]   // *moved = deleted
+	return true
+}
+
+// Keys returns all the keys of the SparseSet in ascending order.
+func (s *SparseSet[K, T]) Keys() []K {
+	keys := make([]K, 0, s.size)
+	s.Each(func(key K, _ *T) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// Values returns copies of all the objects of the SparseSet in the ascending order of their keys.
+func (s *SparseSet[K, T]) Values() []T {
+	values := make([]T, 0, s.size)
+	s.Each(func(_ K, val *T) bool {
+		values = append(values, *val)
+		return true
+	})
+	return values
+}
+
+// Clear deletes all the objects from the SparseSet in O(Len): only the sparse slots referenced from the dense array
+// are reset. The pages and the buckets of the dense array are kept for reuse, call Compact to release them.
+func (s *SparseSet[K, T]) Clear() {
+	s.dense.Each(func(br *backRef[K, T]) bool {
+		pg, slot := s.slot(br.ref)
+		*slot = NULL
+		pg.used--
+		return true
+	})
+	s.dense.Reset()
+	s.size = 0
+}
+
+// Compact releases the pages of the sparse array which have no keys, shrinks the page directory to the highest
+// live page and releases the unused buckets of the dense array. It can be used after deleting a lot of objects,
+// so the SparseSet does not hold the memory of its peak size.
+func (s *SparseSet[K, T]) Compact() {
+	s.sparse.compact()
+	s.dense.Prune()
 }
 
 // Each iterates through all the elements in the Collection and calls the provided callback function for each of
 // the elements. If the callback function returns false, the iteration will be stopped.
+//
+// The elements are visited in the ascending order of keys by scanning the allocated pages of the sparse array,
+// see EachDense for the faster iteration in the order of the dense array.
 func (s *SparseSet[K, T]) Each(callback func(key K, val *T) bool) {
-	for k, v := range s.sparse {
-		if v == NULL {
-			continue
+	s.sparse.each(func(base int, pg *page) bool {
+		for x, v := range pg.slots {
+			if v == NULL {
+				continue
+			}
+			if !callback(K(base|x), &s.dense.Get(v).data) {
+				return false
+			}
 		}
-		gh := callback(K(k), &s.dense.Get(v).data)
-		if !gh {
-			return
-		}
-	}
+		return true
+	})
 }
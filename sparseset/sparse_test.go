@@ -317,3 +317,84 @@ func BenchmarkSparseSetIter18m(b *testing.B) {
 		}
 	})
 }
+
+func TestSparseSetMapAPI(t *testing.T) {
+	t.Parallel()
+	t.Run("get_delete", func(t *testing.T) {
+		t.Parallel()
+
+		sp := New[int, string](0, 0)
+		sp.Set(1, "one")
+		sp.Set(2, "two")
+		require.True(t, sp.Has(1))
+		require.False(t, sp.Has(3))
+		require.False(t, sp.Has(-1))
+
+		val, ok := sp.GetOK(2)
+		require.True(t, ok)
+		require.Equal(t, "two", val)
+		_, ok = sp.GetOK(1 << 30)
+		require.False(t, ok)
+
+		require.False(t, sp.TryDelete(3))
+		require.False(t, sp.TryDelete(1<<30))
+		sp.Delete(-5)
+		require.Equal(t, 2, sp.Len())
+		require.True(t, sp.TryDelete(1))
+		require.False(t, sp.TryDelete(1))
+		require.Equal(t, 1, sp.Len())
+		require.Equal(t, []int{2}, sp.Keys())
+	})
+	t.Run("load_or_store_swap", func(t *testing.T) {
+		t.Parallel()
+
+		sp := New[int, int](0, 0)
+		ref, loaded := sp.LoadOrStore(5, 50)
+		require.False(t, loaded)
+		require.Equal(t, 50, *ref)
+		ref, loaded = sp.LoadOrStore(5, 500)
+		require.True(t, loaded)
+		require.Equal(t, 50, *ref)
+
+		prev, loaded := sp.Swap(5, 55)
+		require.True(t, loaded)
+		require.Equal(t, 50, prev)
+		prev, loaded = sp.Swap(6, 66)
+		require.False(t, loaded)
+		require.Equal(t, 0, prev)
+		require.Equal(t, []int{55, 66}, sp.Values())
+	})
+	t.Run("update", func(t *testing.T) {
+		t.Parallel()
+
+		sp := New[int, int](0, 0)
+		incr := func(val int, ok bool) (int, bool) {
+			return val + 1, true
+		}
+		sp.Update(7, incr)
+		sp.Update(7, incr)
+		require.Equal(t, 2, *sp.Get(7))
+
+		ref := sp.Update(7, func(val int, ok bool) (int, bool) {
+			require.True(t, ok)
+			return 0, false
+		})
+		require.Nil(t, ref)
+		require.False(t, sp.Has(7))
+		require.Equal(t, 0, sp.Len())
+	})
+	t.Run("clear", func(t *testing.T) {
+		t.Parallel()
+
+		sp := New[int, int](0, 0)
+		for i := 0; i < 10_000; i += 3 {
+			sp.Set(i, i)
+		}
+		sp.Clear()
+		require.Equal(t, 0, sp.Len())
+		require.False(t, sp.Has(3))
+		require.Empty(t, sp.Keys())
+		sp.Set(3, 3)
+		require.Equal(t, []int{3}, sp.Keys())
+	})
+}
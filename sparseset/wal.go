@@ -0,0 +1,136 @@
+package sparseset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// Codec encodes and decodes the values that Durable writes to the log and the snapshots.
+type Codec[T any] interface {
+	// Encode writes the value to w.
+	Encode(w io.Writer, val T) error
+	// Decode reads the value written by Encode.
+	Decode(r io.Reader) (T, error)
+}
+
+// GobCodec is the default Codec based on encoding/gob.
+type GobCodec[T any] struct{}
+
+func (GobCodec[T]) Encode(w io.Writer, val T) error {
+	return gob.NewEncoder(w).Encode(&val)
+}
+
+func (GobCodec[T]) Decode(r io.Reader) (T, error) {
+	var val T
+	err := gob.NewDecoder(r).Decode(&val)
+	return val, err
+}
+
+const (
+	opSet    byte = 1
+	opDelete byte = 2
+)
+
+// A record of the log and the snapshot is:
+//
+//	op (1 byte) | key (uvarint) | payload length (uvarint) | payload | CRC-32 of all the preceding bytes (4 bytes LE)
+//
+// The checksum allows to detect the record torn by a crash.
+
+// recordWriter encodes records into the reused buffer, so each record is written with a single Write call.
+type recordWriter[T any] struct {
+	codec   Codec[T]
+	buf     bytes.Buffer
+	payload bytes.Buffer
+	tmp     [binary.MaxVarintLen64]byte
+}
+
+func (w *recordWriter[T]) encode(op byte, key uint64, val *T) ([]byte, error) {
+	w.buf.Reset()
+	w.payload.Reset()
+	if val != nil {
+		if err := w.codec.Encode(&w.payload, *val); err != nil {
+			return nil, err
+		}
+	}
+	w.buf.WriteByte(op)
+	w.buf.Write(w.tmp[:binary.PutUvarint(w.tmp[:], key)])
+	w.buf.Write(w.tmp[:binary.PutUvarint(w.tmp[:], uint64(w.payload.Len()))])
+	w.buf.Write(w.payload.Bytes())
+	binary.LittleEndian.PutUint32(w.tmp[:4], crc32.ChecksumIEEE(w.buf.Bytes()))
+	w.buf.Write(w.tmp[:4])
+	return w.buf.Bytes(), nil
+}
+
+// recordReader decodes records and keeps the offset of the end of the last valid one.
+type recordReader[T any] struct {
+	codec   Codec[T]
+	r       *bufio.Reader
+	crc     hash.Hash32
+	off     int64
+	read    int64
+	payload []byte
+}
+
+func newRecordReader[T any](r io.Reader, codec Codec[T]) *recordReader[T] {
+	return &recordReader[T]{codec: codec, r: bufio.NewReader(r), crc: crc32.NewIEEE()}
+}
+
+func (r *recordReader[T]) ReadByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err == nil {
+		r.crc.Write([]byte{b})
+		r.read++
+	}
+	return b, err
+}
+
+// next reads the next record. It returns io.EOF at the end of the data, and errTorn if the record is incomplete
+// or its checksum does not match.
+func (r *recordReader[T]) next() (op byte, key uint64, val T, err error) {
+	r.crc.Reset()
+	if op, err = r.ReadByte(); err != nil {
+		return op, key, val, err
+	}
+	if op != opSet && op != opDelete {
+		return op, key, val, errTorn
+	}
+	if key, err = binary.ReadUvarint(r); err != nil {
+		return op, key, val, errTorn
+	}
+	size, err := binary.ReadUvarint(r)
+	if err != nil || size > maxPayload {
+		return op, key, val, errTorn
+	}
+	if uint64(cap(r.payload)) < size {
+		r.payload = make([]byte, size)
+	}
+	r.payload = r.payload[:size]
+	if _, err = io.ReadFull(r.r, r.payload); err != nil {
+		return op, key, val, errTorn
+	}
+	r.crc.Write(r.payload)
+	var sum [4]byte
+	if _, err = io.ReadFull(r.r, sum[:]); err != nil || binary.LittleEndian.Uint32(sum[:]) != r.crc.Sum32() {
+		return op, key, val, errTorn
+	}
+	r.read += int64(size) + 4
+	if op == opSet {
+		if val, err = r.codec.Decode(bytes.NewReader(r.payload)); err != nil {
+			return op, key, val, fmt.Errorf("decode value of key %d: %w", key, err)
+		}
+	}
+	r.off = r.read
+	return op, key, val, nil
+}
+
+// maxPayload protects from allocating a huge buffer for a garbage length of a torn record.
+const maxPayload = 1 << 30
+
+var errTorn = fmt.Errorf("torn record")
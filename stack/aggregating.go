@@ -0,0 +1,110 @@
+package stack
+
+// Aggregating is a stack that keeps the running aggregate of its contents, e.g. the minimum, maximum or sum
+// of all the elements, available in O(1) after every Push and Pop.
+//
+// The aggregate is computed with the associative `combine` function, which is applied from the bottom to the top.
+// Each element is stored together with the aggregate of the elements up to it, in Stack's buckets.
+//
+// Elements are returned by copy, as modifying them in place would make the aggregate stale.
+type Aggregating[T any] struct {
+	s       Stack[aggregated[T]]
+	combine func(a, b T) T
+}
+
+type aggregated[T any] struct {
+	val T
+	agg T
+}
+
+// NewAggregating creates a new Aggregating stack with the associative `combine` function.
+func NewAggregating[T any](combine func(a, b T) T) *Aggregating[T] {
+	return &Aggregating[T]{combine: combine}
+}
+
+// Push adds an element to the top of the stack.
+func (c *Aggregating[T]) Push(elem T) {
+	agg := elem
+	if c.s.Len() > 0 {
+		agg = c.combine(c.s.Peek().agg, elem)
+	}
+	c.s.Push(aggregated[T]{val: elem, agg: agg})
+}
+
+// Pop removes and returns the top element of the stack.
+func (c *Aggregating[T]) Pop() T {
+	return c.s.Pop().val
+}
+
+// Peek returns the top element of the stack without removing it.
+func (c *Aggregating[T]) Peek() T {
+	return c.s.Peek().val
+}
+
+// Aggregate returns the aggregate of all the elements of the stack. The second value is false if the stack is empty.
+func (c *Aggregating[T]) Aggregate() (T, bool) {
+	if c.s.Len() == 0 {
+		var empty T
+		return empty, false
+	}
+	return c.s.Peek().agg, true
+}
+
+func (c *Aggregating[T]) Len() int {
+	return c.s.Len()
+}
+
+// AggregatingQueue is a FIFO queue built on two Aggregating stacks, which keeps the aggregate of its contents
+// available in O(1) amortized. It is the classic structure for sliding window minimum/maximum.
+//
+// The aggregate is computed with the associative `combine` function from the oldest element to the newest one.
+type AggregatingQueue[T any] struct {
+	in      *Aggregating[T]
+	out     *Aggregating[T]
+	combine func(a, b T) T
+}
+
+// NewAggregatingQueue creates a new AggregatingQueue with the associative `combine` function.
+func NewAggregatingQueue[T any](combine func(a, b T) T) *AggregatingQueue[T] {
+	return &AggregatingQueue[T]{
+		in: NewAggregating(combine),
+		// the top of the out stack is the oldest element, so its aggregate is combined in the reverse order
+		out: NewAggregating(func(a, b T) T {
+			return combine(b, a)
+		}),
+		combine: combine,
+	}
+}
+
+// Push adds an element to the end of the queue.
+func (q *AggregatingQueue[T]) Push(elem T) {
+	q.in.Push(elem)
+}
+
+// Pop removes and returns the oldest element of the queue.
+func (q *AggregatingQueue[T]) Pop() T {
+	if q.out.Len() == 0 {
+		for q.in.Len() > 0 {
+			q.out.Push(q.in.Pop())
+		}
+	}
+	return q.out.Pop()
+}
+
+// Aggregate returns the aggregate of all the elements of the queue. The second value is false if the queue is empty.
+func (q *AggregatingQueue[T]) Aggregate() (T, bool) {
+	outAgg, outOk := q.out.Aggregate()
+	inAgg, inOk := q.in.Aggregate()
+	switch {
+	case outOk && inOk:
+		return q.combine(outAgg, inAgg), true
+	case outOk:
+		return outAgg, true
+	default:
+		return inAgg, inOk
+	}
+}
+
+func (q *AggregatingQueue[T]) Len() int {
+	return q.in.Len() + q.out.Len()
+}
@@ -0,0 +1,93 @@
+package stack
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func TestAggregating(t *testing.T) {
+	t.Parallel()
+	t.Run("min", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewAggregating(minInt)
+		_, ok := c.Aggregate()
+		require.False(t, ok)
+		for _, v := range []int{5, 7, 3, 8, 1, 9} {
+			c.Push(v)
+		}
+		for _, expected := range []int{1, 1, 3, 3, 5, 5} {
+			agg, ok := c.Aggregate()
+			require.True(t, ok)
+			require.Equal(t, expected, agg)
+			c.Pop()
+		}
+		require.Equal(t, 0, c.Len())
+	})
+	t.Run("not_commutative", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewAggregating(func(a, b string) string {
+			return a + b
+		})
+		c.Push("a")
+		c.Push("b")
+		c.Push("c")
+		agg, _ := c.Aggregate()
+		require.Equal(t, "abc", agg)
+		require.Equal(t, "c", c.Peek())
+	})
+}
+
+func TestAggregatingQueue(t *testing.T) {
+	t.Parallel()
+	t.Run("sliding_min", func(t *testing.T) {
+		t.Parallel()
+
+		const window = 16
+		var (
+			rnd    = rand.New(rand.NewSource(3))
+			q      = NewAggregatingQueue(minInt)
+			values []int
+		)
+		for n := 0; n < 5000; n++ {
+			v := rnd.Intn(1000)
+			values = append(values, v)
+			q.Push(v)
+			if q.Len() > window {
+				require.Equal(t, values[n-window], q.Pop())
+			}
+			expected := values[n]
+			for x := n; x >= 0 && x > n-window; x-- {
+				expected = minInt(expected, values[x])
+			}
+			agg, ok := q.Aggregate()
+			require.True(t, ok)
+			require.Equal(t, expected, agg)
+		}
+	})
+	t.Run("order", func(t *testing.T) {
+		t.Parallel()
+
+		q := NewAggregatingQueue(func(a, b string) string {
+			return a + b
+		})
+		q.Push("a")
+		q.Push("b")
+		q.Push("c")
+		require.Equal(t, "a", q.Pop())
+		q.Push("d")
+		agg, ok := q.Aggregate()
+		require.True(t, ok)
+		require.Equal(t, "bcd", agg)
+	})
+}
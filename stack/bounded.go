@@ -0,0 +1,98 @@
+package stack
+
+// EvictionMode defines how Bounded stack drops the oldest elements.
+type EvictionMode int
+
+const (
+	// EvictExact drops exactly as many bottom elements as needed, so the stack always holds up to max elements.
+	EvictExact EvictionMode = iota
+	// EvictBucket drops the whole bottom bucket at once, which is cheaper,
+	// but the stack holds from max minus the bucket size to max elements.
+	EvictBucket
+)
+
+// Bounded is a stack with the maximum size: pushing beyond the capacity drops the bottom-most elements,
+// which suits the lists of "recent N actions".
+//
+// The buckets are a quarter of the capacity, so the eviction of a whole bottom bucket is just unlinking it.
+type Bounded[T any] struct {
+	s       *Stack[T]
+	max     int
+	mode    EvictionMode
+	onEvict func(T)
+}
+
+// NewBounded creates a new Bounded stack with the specified capacity and eviction mode.
+// The optional `onEvict` callback is called for each evicted element, from the oldest one.
+func NewBounded[T any](max int, mode EvictionMode, onEvict func(T)) *Bounded[T] {
+	if max < 1 {
+		max = 1
+	}
+	bsz := max / 4
+	if bsz < 1 {
+		bsz = 1
+	}
+	return &Bounded[T]{
+		s: NewWithOptions[T](Options{
+			InitialBucketSize: bsz,
+			MaxBucketSize:     bsz,
+			GrowthFactor:      1,
+		}),
+		max:     max,
+		mode:    mode,
+		onEvict: onEvict,
+	}
+}
+
+// Push adds an element to the top of the stack, evicting the oldest elements if the capacity is exceeded.
+//
+// The returned reference is valid until the element is popped or evicted.
+func (c *Bounded[T]) Push(elem T) *T {
+	ref := c.s.Push(elem)
+	for c.s.Len() > c.max {
+		n := c.s.Len() - c.max
+		if c.mode == EvictBucket {
+			n = c.s.BottomBucketLen()
+		}
+		c.evict(n)
+	}
+	return ref
+}
+
+func (c *Bounded[T]) evict(n int) {
+	if c.onEvict != nil {
+		for i := 0; i < n; i++ {
+			c.onEvict(*c.s.Get(i))
+		}
+	}
+	c.s.DropBottom(n)
+}
+
+// Pop removes and returns the top element of the stack.
+func (c *Bounded[T]) Pop() T {
+	return c.s.Pop()
+}
+
+// Peek returns the top element of the stack without removing it.
+func (c *Bounded[T]) Peek() *T {
+	return c.s.Peek()
+}
+
+// Get returns the element located at the position `i` counting from the oldest element without removing it.
+func (c *Bounded[T]) Get(i int) *T {
+	return c.s.Get(i)
+}
+
+func (c *Bounded[T]) Len() int {
+	return c.s.Len()
+}
+
+// Cap returns the maximum count of elements.
+func (c *Bounded[T]) Cap() int {
+	return c.max
+}
+
+// Clear removes all the elements from the stack without calling the eviction callback.
+func (c *Bounded[T]) Clear() {
+	c.s.Clear()
+}
@@ -0,0 +1,84 @@
+package stack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBounded(t *testing.T) {
+	t.Parallel()
+	t.Run("exact", func(t *testing.T) {
+		t.Parallel()
+
+		var evicted []int
+		c := NewBounded[int](10, EvictExact, func(v int) {
+			evicted = append(evicted, v)
+		})
+		for n := 0; n < 25; n++ {
+			c.Push(n)
+			require.LessOrEqual(t, c.Len(), 10)
+		}
+		require.Equal(t, 10, c.Len())
+		require.Equal(t, 10, c.Cap())
+		for n := 0; n < 10; n++ {
+			require.Equal(t, n+15, *c.Get(n))
+		}
+		require.Len(t, evicted, 15)
+		for n, v := range evicted {
+			require.Equal(t, n, v)
+		}
+		require.Equal(t, 24, c.Pop())
+		require.Equal(t, 23, *c.Peek())
+	})
+	t.Run("exact_bucket_shape", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewBounded[int](1000, EvictExact, nil)
+		for n := 0; n < 100_000; n++ {
+			c.Push(n)
+		}
+		require.Equal(t, 1000, c.Len())
+		// the steady state is max/bucket size buckets plus the partially evicted bottom one, all of them full-sized
+		require.LessOrEqual(t, len(c.s.dir), 5)
+		for _, b := range c.s.dir {
+			require.Equal(t, 250, cap(b.cont))
+		}
+		for n := 0; n < 1000; n++ {
+			require.Equal(t, 99_000+n, *c.Get(n))
+		}
+	})
+	t.Run("bucket", func(t *testing.T) {
+		t.Parallel()
+
+		var evicted []int
+		c := NewBounded[int](100, EvictBucket, func(v int) {
+			evicted = append(evicted, v)
+		})
+		for n := 0; n < 1000; n++ {
+			c.Push(n)
+			require.LessOrEqual(t, c.Len(), 100)
+			if n >= 100 {
+				require.GreaterOrEqual(t, c.Len(), 76)
+			}
+		}
+		require.Equal(t, 1000-c.Len(), len(evicted))
+		for n, v := range evicted {
+			require.Equal(t, n, v)
+		}
+		for n := 0; n < c.Len(); n++ {
+			require.Equal(t, len(evicted)+n, *c.Get(n))
+		}
+	})
+	t.Run("no_callback", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewBounded[string](1, EvictExact, nil)
+		c.Push("foo")
+		c.Push("bar")
+		require.Equal(t, 1, c.Len())
+		require.Equal(t, "bar", *c.Peek())
+		c.Clear()
+		require.Equal(t, 0, c.Len())
+	})
+}
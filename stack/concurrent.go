@@ -0,0 +1,101 @@
+package stack
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// Concurrent is a lock-free LIFO stack which is safe for use by multiple goroutines, e.g. as a shared free list.
+//
+// It is a Treiber stack: the top is a linked list head swapped with compare-and-swap. When the swap fails because of
+// contention, the goroutine backs off to the elimination array, where a Push and a Pop that run at the same time can
+// exchange the value directly without touching the head at all.
+//
+// Unlike Stack, every pushed element is a separate node, and values can not be referenced.
+// The zero value is an empty stack ready to use.
+type Concurrent[T any] struct {
+	top   unsafe.Pointer // *node[T]
+	count int64
+	slots [eliminationSz]unsafe.Pointer // *node[T] offered by pushers
+}
+
+type node[T any] struct {
+	val  T
+	next unsafe.Pointer // *node[T]
+}
+
+const (
+	eliminationSz   = 8
+	eliminationSpin = 64
+)
+
+// Push adds an element to the top of the stack.
+func (c *Concurrent[T]) Push(elem T) {
+	n := &node[T]{val: elem}
+	for {
+		top := atomic.LoadPointer(&c.top)
+		n.next = top
+		if atomic.CompareAndSwapPointer(&c.top, top, unsafe.Pointer(n)) {
+			atomic.AddInt64(&c.count, 1)
+			return
+		}
+		if c.offer(n) {
+			return
+		}
+	}
+}
+
+// Pop removes and returns the top element of the stack. The second value is false if the stack is empty.
+func (c *Concurrent[T]) Pop() (T, bool) {
+	for {
+		top := atomic.LoadPointer(&c.top)
+		if top == nil {
+			// a pusher could be waiting in the elimination array
+			if n := c.take(); n != nil {
+				return n.val, true
+			}
+			var empty T
+			return empty, false
+		}
+		n := (*node[T])(top)
+		if atomic.CompareAndSwapPointer(&c.top, top, atomic.LoadPointer(&n.next)) {
+			atomic.AddInt64(&c.count, -1)
+			return n.val, true
+		}
+		if n = c.take(); n != nil {
+			return n.val, true
+		}
+	}
+}
+
+// Len returns the count of elements. Under concurrent modification it is only an estimate.
+func (c *Concurrent[T]) Len() int {
+	return int(atomic.LoadInt64(&c.count))
+}
+
+// offer places the node into the elimination array and waits a little for a popper to take it.
+// It returns true if the node was taken, so the push is done.
+func (c *Concurrent[T]) offer(n *node[T]) bool {
+	slot := &c.slots[(uintptr(unsafe.Pointer(n))>>4)%eliminationSz]
+	if !atomic.CompareAndSwapPointer(slot, nil, unsafe.Pointer(n)) {
+		return false
+	}
+	for i := 0; i < eliminationSpin; i++ {
+		if atomic.LoadPointer(slot) != unsafe.Pointer(n) {
+			return true
+		}
+	}
+	// withdraw the offer, failure means that a popper has already taken the node
+	return !atomic.CompareAndSwapPointer(slot, unsafe.Pointer(n), nil)
+}
+
+// take tries to get a node offered by a concurrent pusher.
+func (c *Concurrent[T]) take() *node[T] {
+	for i := range c.slots {
+		p := atomic.LoadPointer(&c.slots[i])
+		if p != nil && atomic.CompareAndSwapPointer(&c.slots[i], p, nil) {
+			return (*node[T])(p)
+		}
+	}
+	return nil
+}
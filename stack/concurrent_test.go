@@ -0,0 +1,107 @@
+package stack
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrent(t *testing.T) {
+	t.Parallel()
+	t.Run("lifo", func(t *testing.T) {
+		t.Parallel()
+
+		var c Concurrent[int]
+		_, ok := c.Pop()
+		require.False(t, ok)
+		for n := 0; n < 100; n++ {
+			c.Push(n)
+		}
+		require.Equal(t, 100, c.Len())
+		for n := 99; n >= 0; n-- {
+			v, ok := c.Pop()
+			require.True(t, ok)
+			require.Equal(t, n, v)
+		}
+		require.Equal(t, 0, c.Len())
+	})
+	t.Run("parallel", func(t *testing.T) {
+		t.Parallel()
+
+		const (
+			workers = 8
+			count   = 20_000
+		)
+		var (
+			c      Concurrent[int]
+			wg     sync.WaitGroup
+			popped = make([][]int, workers)
+			seen   = make([]bool, workers*count)
+		)
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for n := 0; n < count; n++ {
+					c.Push(w*count + n)
+					if n%2 == 1 {
+						for k := 0; k < 2; k++ {
+							if v, ok := c.Pop(); ok {
+								popped[w] = append(popped[w], v)
+							}
+						}
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+		for _, values := range popped {
+			for _, v := range values {
+				require.False(t, seen[v], "duplicate %d", v)
+				seen[v] = true
+			}
+		}
+		for {
+			v, ok := c.Pop()
+			if !ok {
+				break
+			}
+			require.False(t, seen[v], "duplicate %d", v)
+			seen[v] = true
+		}
+		for v, ok := range seen {
+			require.True(t, ok, "lost %d", v)
+		}
+	})
+}
+
+func BenchmarkConcurrent(b *testing.B) {
+	b.Run("Treiber", func(b *testing.B) {
+		var c Concurrent[int]
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				c.Push(1)
+				c.Pop()
+			}
+		})
+	})
+	b.Run("Mutex", func(b *testing.B) {
+		var (
+			c  Stack[int]
+			mu sync.Mutex
+		)
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				mu.Lock()
+				c.Push(1)
+				mu.Unlock()
+				mu.Lock()
+				c.Pop()
+				mu.Unlock()
+			}
+		})
+	})
+}
@@ -0,0 +1,64 @@
+package stack
+
+import "github.com/iv-menshenin/fusion/errors"
+
+// DropBottom removes `n` oldest elements from the bottom of the stack.
+//
+// Whole buckets are unlinked and go to the cache for reuse. In the bottom bucket the evicted cells are only cleared
+// and stay there until the whole bucket is unlinked, so the bucket keeps its capacity, and the cost does not depend
+// on the count of elements left in the stack.
+func (c *Stack[T]) DropBottom(n int) {
+	if n < 0 || n > c.count {
+		panic(errors.OutOfBounds(c.count, n))
+	}
+	var empty T
+	for n > 0 {
+		var (
+			b    = c.dir[0]
+			dead = c.lo - b.base // cells evicted earlier
+			k    = b.count - dead
+		)
+		if k > n {
+			k = n
+		}
+		for x := dead; x < dead+k; x++ {
+			b.cont[x] = empty
+		}
+		c.count -= k
+		c.lo += k
+		n -= k
+		switch {
+		case dead+k < b.count:
+			// the bucket still has live elements
+		case len(c.dir) > 1:
+			// unlink the bucket
+			c.dir[0] = nil
+			c.dir = c.dir[1:]
+			c.dir[0].prev = nil
+			b.count, b.prev = 0, nil
+			c.cacheBucket(b)
+		default:
+			// the only bucket is kept as it is
+			c.resetEmpty()
+		}
+	}
+}
+
+// resetEmpty drops the evicted cells from the bottom bucket when the stack becomes empty,
+// so the evicted cells never stay below the live elements of another bucket.
+func (c *Stack[T]) resetEmpty() {
+	if c.count == 0 && len(c.dir) > 0 {
+		b := c.dir[0]
+		b.count, b.base = 0, c.lo
+	}
+}
+
+// BottomBucketLen returns the count of elements in the bottom bucket. Evicting them with DropBottom
+// unlinks the whole bucket, which is the cheapest way to limit the size of the stack.
+func (c *Stack[T]) BottomBucketLen() int {
+	if len(c.dir) == 0 {
+		return 0
+	}
+	b := c.dir[0]
+	return b.count - (c.lo - b.base)
+}
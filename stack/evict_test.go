@@ -0,0 +1,105 @@
+package stack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDropBottom(t *testing.T) {
+	t.Parallel()
+	t.Run("exact", func(t *testing.T) {
+		t.Parallel()
+
+		var c Stack[int]
+		for n := 0; n < 5000; n++ {
+			c.Push(n)
+		}
+		c.DropBottom(1500)
+		require.Equal(t, 3500, c.Len())
+		require.Len(t, c.dir, 2)
+		for n := 0; n < 3500; n++ {
+			require.Equal(t, n+1500, *c.Get(n))
+		}
+		require.Equal(t, 1500, c.BottomBucketLen())
+
+		for n := 5000; n < 10_000; n++ {
+			c.Push(n)
+		}
+		for n := 0; n < 8500; n++ {
+			require.Equal(t, n+1500, *c.Get(n))
+		}
+		require.Equal(t, []int{9998, 9999}, c.PopN(2, nil))
+		c.Truncate(100)
+		require.Equal(t, 1599, *c.Peek())
+		for n := 99; n >= 0; n-- {
+			require.Equal(t, n+1500, c.Pop())
+		}
+	})
+	t.Run("whole_buckets", func(t *testing.T) {
+		t.Parallel()
+
+		var c Stack[*int]
+		for n := 0; n < 7000; n++ {
+			v := n
+			c.Push(&v)
+		}
+		require.Equal(t, 1000, c.BottomBucketLen())
+		cached := c.cached
+		c.DropBottom(c.BottomBucketLen())
+		require.Equal(t, 6000, c.Len())
+		require.Len(t, c.dir, 2)
+		require.Greater(t, c.cached, cached)
+		require.Equal(t, 1000, **c.Get(0))
+
+		top := c.SplitTop(3000)
+		require.Equal(t, 4000, **top.Get(0))
+		c.PushStack(top)
+		for n := 0; n < 6000; n++ {
+			require.Equal(t, n+1000, **c.Get(n))
+		}
+	})
+	t.Run("all", func(t *testing.T) {
+		t.Parallel()
+
+		var c Stack[int]
+		for n := 0; n < 10; n++ {
+			c.Push(n)
+		}
+		c.DropBottom(10)
+		require.Equal(t, 0, c.Len())
+		require.Panics(t, func() {
+			c.DropBottom(1)
+		})
+		c.Push(42)
+		require.Equal(t, 42, *c.Get(0))
+		require.Equal(t, 42, c.Pop())
+	})
+	t.Run("keeps_capacity", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewWithOptions[int](Options{InitialBucketSize: 100, MaxBucketSize: 100, GrowthFactor: 1})
+		for n := 0; n < 100_000; n++ {
+			c.Push(n)
+			if c.Len() > 300 {
+				c.DropBottom(1)
+			}
+		}
+		require.Equal(t, 300, c.Len())
+		require.LessOrEqual(t, len(c.dir), 4)
+		for _, b := range c.dir {
+			require.Equal(t, 100, cap(b.cont))
+		}
+		for n := 0; n < 300; n++ {
+			require.Equal(t, 99_700+n, *c.Get(n))
+		}
+
+		// the evicted cells are dropped when the stack becomes empty
+		c.DropBottom(c.Len() - 1)
+		c.Pop()
+		c.Push(1)
+		c.Push(2)
+		require.Len(t, c.dir, 1)
+		require.Equal(t, 2, c.BottomBucketLen())
+	})
+}
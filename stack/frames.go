@@ -0,0 +1,106 @@
+package stack
+
+import "github.com/iv-menshenin/fusion/errors"
+
+// FrameStack is a Stack with frame markers, which suits the call frames of an interpreter:
+// PushFrame marks the current top as the base of a new frame, the elements pushed after that are the locals
+// of the frame, and PopFrame releases all of them at once.
+//
+// All the Stack methods are available, but popping the elements below the base of the current frame
+// with Pop breaks the frame, so use PopFrame to leave a frame.
+type FrameStack[T any] struct {
+	Stack[T]
+	frames []int
+}
+
+// Frame is a handle of a frame. It stays valid until the frame is popped.
+type Frame struct {
+	depth int
+	base  int
+}
+
+// Depth returns the position of the frame, counting from the bottom starting with zero.
+func (f Frame) Depth() int {
+	return f.depth
+}
+
+// Base returns the position in the stack of the first element of the frame.
+func (f Frame) Base() int {
+	return f.base
+}
+
+// PushFrame starts a new frame at the top of the stack and returns its handle.
+func (c *FrameStack[T]) PushFrame() Frame {
+	c.frames = append(c.frames, c.count)
+	return Frame{depth: len(c.frames) - 1, base: c.count}
+}
+
+// PopFrame removes the current frame and all its elements from the stack. The emptied buckets are kept for reuse.
+// It panics if there are no frames.
+func (c *FrameStack[T]) PopFrame() {
+	if len(c.frames) == 0 {
+		panic(errors.OutOfBounds(0, 0))
+	}
+	base := c.frames[len(c.frames)-1]
+	c.frames = c.frames[:len(c.frames)-1]
+	if base < c.count {
+		c.Truncate(base)
+	}
+}
+
+// Depth returns the count of frames.
+func (c *FrameStack[T]) Depth() int {
+	return len(c.frames)
+}
+
+// Current returns the handle of the current frame. It panics if there are no frames.
+func (c *FrameStack[T]) Current() Frame {
+	if len(c.frames) == 0 {
+		panic(errors.OutOfBounds(0, 0))
+	}
+	return Frame{depth: len(c.frames) - 1, base: c.frames[len(c.frames)-1]}
+}
+
+// Local returns the element located at the position `i` of the current frame.
+func (c *FrameStack[T]) Local(i int) *T {
+	return c.LocalOf(c.Current(), i)
+}
+
+// LocalOf returns the element located at the position `i` of the given frame.
+// It panics if the frame was popped or the position is out of the frame.
+func (c *FrameStack[T]) LocalOf(f Frame, i int) *T {
+	c.checkFrame(f)
+	if size := c.FrameLen(f); i < 0 || i >= size {
+		panic(errors.OutOfBounds(size, i))
+	}
+	return c.Get(f.base + i)
+}
+
+// FrameLen returns the count of elements that belong to the given frame.
+func (c *FrameStack[T]) FrameLen(f Frame) int {
+	c.checkFrame(f)
+	end := c.count
+	if f.depth+1 < len(c.frames) {
+		end = c.frames[f.depth+1]
+	}
+	if end < f.base {
+		return 0
+	}
+	return end - f.base
+}
+
+// Frames iterates through the frames from the top to the bottom, which is the order of a stack trace.
+// If the callback function returns false, the iteration will be stopped.
+func (c *FrameStack[T]) Frames(callback func(f Frame) bool) {
+	for depth := len(c.frames) - 1; depth >= 0; depth-- {
+		if !callback(Frame{depth: depth, base: c.frames[depth]}) {
+			return
+		}
+	}
+}
+
+func (c *FrameStack[T]) checkFrame(f Frame) {
+	if f.depth < 0 || f.depth >= len(c.frames) || c.frames[f.depth] != f.base {
+		panic(errors.OutOfBounds(len(c.frames), f.depth))
+	}
+}
@@ -0,0 +1,75 @@
+package stack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrameStack(t *testing.T) {
+	t.Parallel()
+	t.Run("locals", func(t *testing.T) {
+		t.Parallel()
+
+		var c FrameStack[int]
+		c.Push(-1) // global
+		main := c.PushFrame()
+		c.Push(10)
+		c.Push(11)
+		call := c.PushFrame()
+		c.Push(20)
+
+		require.Equal(t, 2, c.Depth())
+		require.Equal(t, 20, *c.Local(0))
+		require.Equal(t, 11, *c.LocalOf(main, 1))
+		require.Equal(t, 2, c.FrameLen(main))
+		require.Equal(t, 1, c.FrameLen(call))
+		require.Panics(t, func() {
+			c.Local(1)
+		})
+
+		*c.Local(0) = 21
+		require.Equal(t, 21, *c.Peek())
+
+		c.PopFrame()
+		require.Equal(t, 3, c.Len())
+		require.Equal(t, 11, *c.Peek())
+		require.Panics(t, func() {
+			c.LocalOf(call, 0)
+		})
+
+		c.PopFrame()
+		require.Equal(t, 1, c.Len())
+		require.Equal(t, 0, c.Depth())
+		require.Panics(t, func() {
+			c.PopFrame()
+		})
+	})
+	t.Run("deep", func(t *testing.T) {
+		t.Parallel()
+
+		var c FrameStack[int]
+		const depth = 10_000
+		for n := 0; n < depth; n++ {
+			c.PushFrame()
+			for x := 0; x < n%5; x++ {
+				c.Push(n)
+			}
+		}
+		var trace []int
+		c.Frames(func(f Frame) bool {
+			trace = append(trace, f.Depth())
+			if size := c.FrameLen(f); size > 0 {
+				require.Equal(t, f.Depth(), *c.LocalOf(f, size-1))
+			}
+			return len(trace) < 3
+		})
+		require.Equal(t, []int{depth - 1, depth - 2, depth - 3}, trace)
+
+		for n := depth - 1; n >= 0; n-- {
+			require.Equal(t, n, c.Current().Depth())
+			c.PopFrame()
+		}
+		require.Equal(t, 0, c.Len())
+	})
+}
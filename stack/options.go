@@ -0,0 +1,60 @@
+package stack
+
+// Options configures the growth policy of a Stack. Zero fields are replaced with the default values.
+type Options struct {
+	// InitialBucketSize is the capacity of the first bucket. The default is 1000.
+	InitialBucketSize int
+	// MaxBucketSize limits the capacity of a bucket. The default is 1_000_000.
+	MaxBucketSize int
+	// GrowthFactor is the ratio between the capacities of a new bucket and the previous one. The default is 2,
+	// and 1 makes all the buckets equal.
+	GrowthFactor float64
+	// CacheLimit is the count of bucket headers allocated at once, which is also the maximum count of emptied buckets
+	// kept for reuse. The default is 32.
+	CacheLimit int
+	// ShrinkRatio enables releasing the cached buckets: when the length of the stack becomes less than 1/ShrinkRatio
+	// of the capacity of the live and cached buckets, the cached buckets are released. Zero keeps them forever.
+	ShrinkRatio int
+}
+
+var defaultOptions = Options{
+	InitialBucketSize: firstBucketSz,
+	MaxBucketSize:     maxBucketSz,
+	GrowthFactor:      growthFactor,
+	CacheLimit:        bucketsCache,
+}
+
+// NewWithOptions creates a new Stack with the specified growth policy.
+//
+// Tiny stacks benefit from a small initial bucket and cache limit, while giant stacks can use a larger max bucket size.
+func NewWithOptions[T any](opts Options) *Stack[T] {
+	if opts.InitialBucketSize <= 0 {
+		opts.InitialBucketSize = defaultOptions.InitialBucketSize
+	}
+	if opts.MaxBucketSize <= 0 {
+		opts.MaxBucketSize = defaultOptions.MaxBucketSize
+	}
+	if opts.MaxBucketSize < opts.InitialBucketSize {
+		opts.MaxBucketSize = opts.InitialBucketSize
+	}
+	if opts.GrowthFactor == 0 {
+		opts.GrowthFactor = defaultOptions.GrowthFactor
+	}
+	if opts.GrowthFactor < 1 {
+		opts.GrowthFactor = 1
+	}
+	if opts.CacheLimit <= 0 {
+		opts.CacheLimit = defaultOptions.CacheLimit
+	}
+	if opts.ShrinkRatio < 0 {
+		opts.ShrinkRatio = 0
+	}
+	return &Stack[T]{opts: &opts}
+}
+
+func (c *Stack[T]) options() *Options {
+	if c.opts == nil {
+		return &defaultOptions
+	}
+	return c.opts
+}
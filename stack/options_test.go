@@ -0,0 +1,77 @@
+package stack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	t.Parallel()
+	t.Run("tiny", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewWithOptions[int](Options{InitialBucketSize: 4, MaxBucketSize: 16, CacheLimit: 2})
+		for n := 0; n < 100; n++ {
+			c.Push(n)
+		}
+		require.Equal(t, 4, cap(c.dir[0].cont))
+		require.Equal(t, 8, cap(c.dir[1].cont))
+		require.Equal(t, 16, cap(c.dir[2].cont))
+		require.Equal(t, 16, cap(c.dir[3].cont))
+		for n := 0; n < 100; n++ {
+			require.Equal(t, n, *c.Get(n))
+		}
+		for n := 99; n >= 0; n-- {
+			require.Equal(t, n, c.Pop())
+		}
+	})
+	t.Run("growth_factor", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewWithOptions[int](Options{InitialBucketSize: 10, GrowthFactor: 1.5})
+		for n := 0; n < 100; n++ {
+			c.Push(n)
+		}
+		require.Equal(t, 10, cap(c.dir[0].cont))
+		require.Equal(t, 15, cap(c.dir[1].cont))
+		require.Equal(t, 22, cap(c.dir[2].cont))
+
+		c = NewWithOptions[int](Options{InitialBucketSize: 10, GrowthFactor: 1})
+		for n := 0; n < 100; n++ {
+			c.Push(n)
+		}
+		require.Len(t, c.dir, 10)
+	})
+	t.Run("shrink_release", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewWithOptions[int](Options{InitialBucketSize: 1000, ShrinkRatio: 4})
+		for n := 0; n < 15_000; n++ {
+			c.Push(n)
+		}
+		c.Truncate(7000)
+		require.Equal(t, 8000, c.cached)
+		c.Truncate(2999)
+		require.Equal(t, 0, c.cached)
+		for _, b := range c.cache {
+			require.Nil(t, b.cont)
+		}
+		for n := 2999; n < 15_000; n++ {
+			c.Push(n)
+		}
+		for n := 0; n < 15_000; n++ {
+			require.Equal(t, n, *c.Get(n))
+		}
+	})
+	t.Run("keep_cache", func(t *testing.T) {
+		t.Parallel()
+
+		var c Stack[int]
+		for n := 0; n < 15_000; n++ {
+			c.Push(n)
+		}
+		c.Clear()
+		require.Equal(t, 14_000, c.cached)
+	})
+}
@@ -0,0 +1,210 @@
+package stack
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/iv-menshenin/fusion/errors"
+)
+
+// Codec encodes and decodes the chunks of elements that Spilling stack writes to disk.
+type Codec[T any] interface {
+	// Encode writes all the elements to w.
+	Encode(w io.Writer, elems []T) error
+	// Decode reads the elements written by Encode and appends them to dst.
+	Decode(r io.Reader, dst []T) ([]T, error)
+}
+
+// GobCodec is the default Codec based on encoding/gob.
+type GobCodec[T any] struct{}
+
+func (GobCodec[T]) Encode(w io.Writer, elems []T) error {
+	return gob.NewEncoder(w).Encode(elems)
+}
+
+func (GobCodec[T]) Decode(r io.Reader, dst []T) ([]T, error) {
+	var elems []T
+	if err := gob.NewDecoder(r).Decode(&elems); err != nil {
+		return dst, err
+	}
+	return append(dst, elems...), nil
+}
+
+// Spilling is a stack for very deep traversals that do not fit in memory. It keeps up to `threshold` elements
+// in memory, and when the threshold is exceeded, the cold bottom half is written to a temporary file.
+// The spilled chunks are read back when the pops reach them.
+//
+// The file is a stack of chunks too: a chunk that is read back is truncated from the end of the file,
+// so the disk space is released as the stack shrinks.
+//
+// The API is the same as Stack's, except that I/O errors cause a panic, as there is no way to return them.
+// Call Close to remove the temporary file.
+type Spilling[T any] struct {
+	hot       *Stack[T]
+	codec     Codec[T]
+	threshold int
+	chunk     int
+	dir       string
+	file      *os.File
+	chunks    []spilledChunk
+	spilled   int
+	buf       []T
+}
+
+type spilledChunk struct {
+	off   int64
+	size  int64
+	count int
+}
+
+// NewSpilling creates a new Spilling stack that keeps up to `threshold` elements in memory and writes
+// the temporary file to `dir` (os.TempDir() if empty). If the codec is nil, GobCodec is used.
+func NewSpilling[T any](threshold int, dir string, codec Codec[T]) *Spilling[T] {
+	if threshold < 2 {
+		threshold = 2
+	}
+	if codec == nil {
+		codec = GobCodec[T]{}
+	}
+	chunk := threshold / 2
+	return &Spilling[T]{
+		hot: NewWithOptions[T](Options{
+			InitialBucketSize: chunk,
+			MaxBucketSize:     chunk,
+			GrowthFactor:      1,
+		}),
+		codec:     codec,
+		threshold: threshold,
+		chunk:     chunk,
+		dir:       dir,
+	}
+}
+
+// Push adds an element to the top of the stack.
+//
+// The returned reference is valid only until the next Push, as the element can be spilled to disk.
+func (c *Spilling[T]) Push(elem T) *T {
+	if c.hot.Len() >= c.threshold {
+		c.spill()
+	}
+	return c.hot.Push(elem)
+}
+
+// Pop removes and returns the top element of the stack.
+func (c *Spilling[T]) Pop() T {
+	if c.hot.Len() == 0 {
+		c.load()
+	}
+	return c.hot.Pop()
+}
+
+// Peek returns the top element of the stack without removing it.
+func (c *Spilling[T]) Peek() *T {
+	if c.hot.Len() == 0 {
+		c.load()
+	}
+	return c.hot.Peek()
+}
+
+func (c *Spilling[T]) Len() int {
+	return c.hot.Len() + c.spilled
+}
+
+// Spilled returns the count of elements stored on disk.
+func (c *Spilling[T]) Spilled() int {
+	return c.spilled
+}
+
+// Close removes the temporary file. The stack must not be used after Close.
+func (c *Spilling[T]) Close() error {
+	if c.file == nil {
+		return nil
+	}
+	name := c.file.Name()
+	err := c.file.Close()
+	if rmErr := os.Remove(name); err == nil {
+		err = rmErr
+	}
+	c.file = nil
+	c.chunks = nil
+	c.spilled = 0
+	return err
+}
+
+// spill writes the bottom chunk of the elements in memory to the file.
+func (c *Spilling[T]) spill() {
+	if c.file == nil {
+		f, err := os.CreateTemp(c.dir, "fusion-stack-*")
+		if err != nil {
+			panic(fmt.Errorf("stack: can't create spill file: %w", err))
+		}
+		c.file = f
+	}
+	var off int64
+	if len(c.chunks) > 0 {
+		last := c.chunks[len(c.chunks)-1]
+		off = last.off + last.size
+	}
+	c.buf = c.buf[:0]
+	for i := 0; i < c.chunk; i++ {
+		c.buf = append(c.buf, *c.hot.Get(i))
+	}
+	w := &offsetWriter{f: c.file, off: off}
+	bw := bufio.NewWriter(w)
+	if err := c.codec.Encode(bw, c.buf); err != nil {
+		panic(fmt.Errorf("stack: can't encode spilled chunk: %w", err))
+	}
+	if err := bw.Flush(); err != nil {
+		panic(fmt.Errorf("stack: can't write spilled chunk: %w", err))
+	}
+	c.chunks = append(c.chunks, spilledChunk{off: off, size: w.off - off, count: c.chunk})
+	c.spilled += c.chunk
+	c.hot.DropBottom(c.chunk)
+	c.clearBuf()
+}
+
+// load reads the top chunk back from the file.
+func (c *Spilling[T]) load() {
+	if len(c.chunks) == 0 {
+		panic(errors.OutOfBounds(0, 0))
+	}
+	last := c.chunks[len(c.chunks)-1]
+	r := bufio.NewReader(io.NewSectionReader(c.file, last.off, last.size))
+	buf, err := c.codec.Decode(r, c.buf[:0])
+	if err != nil {
+		panic(fmt.Errorf("stack: can't decode spilled chunk: %w", err))
+	}
+	if len(buf) != last.count {
+		panic(fmt.Errorf("stack: spilled chunk is corrupted: expected %d elements, got %d", last.count, len(buf)))
+	}
+	c.buf = buf
+	c.hot.PushSlice(c.buf)
+	c.clearBuf()
+	c.chunks = c.chunks[:len(c.chunks)-1]
+	c.spilled -= last.count
+	if err = c.file.Truncate(last.off); err != nil {
+		panic(fmt.Errorf("stack: can't truncate spill file: %w", err))
+	}
+}
+
+func (c *Spilling[T]) clearBuf() {
+	var empty T
+	for i := range c.buf {
+		c.buf[i] = empty
+	}
+	c.buf = c.buf[:0]
+}
+
+type offsetWriter struct {
+	f   *os.File
+	off int64
+}
+
+func (w *offsetWriter) Write(p []byte) (int, error) {
+	n, err := w.f.WriteAt(p, w.off)
+	w.off += int64(n)
+	return n, err
+}
@@ -0,0 +1,92 @@
+package stack
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type uint64Codec struct{}
+
+func (uint64Codec) Encode(w io.Writer, elems []uint64) error {
+	return binary.Write(w, binary.LittleEndian, append([]uint64{uint64(len(elems))}, elems...))
+}
+
+func (uint64Codec) Decode(r io.Reader, dst []uint64) ([]uint64, error) {
+	var n uint64
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return dst, err
+	}
+	elems := make([]uint64, n)
+	if err := binary.Read(r, binary.LittleEndian, elems); err != nil {
+		return dst, err
+	}
+	return append(dst, elems...), nil
+}
+
+func TestSpilling(t *testing.T) {
+	t.Parallel()
+	t.Run("gob", func(t *testing.T) {
+		t.Parallel()
+
+		type Elem struct {
+			I int
+			S string
+		}
+		dir := t.TempDir()
+		c := NewSpilling[Elem](100, dir, nil)
+		defer c.Close()
+
+		const count = 10_000
+		for n := 0; n < count; n++ {
+			c.Push(Elem{I: n, S: "elem"})
+			require.LessOrEqual(t, c.Len()-c.Spilled(), 100)
+		}
+		require.Equal(t, count, c.Len())
+		require.Greater(t, c.Spilled(), 0)
+
+		for n := count - 1; n >= count/2; n-- {
+			require.Equal(t, n, c.Peek().I)
+			require.Equal(t, Elem{I: n, S: "elem"}, c.Pop())
+		}
+		// grow again
+		for n := count / 2; n < count; n++ {
+			c.Push(Elem{I: n})
+		}
+		for n := count - 1; n >= 0; n-- {
+			require.Equal(t, n, c.Pop().I)
+		}
+		require.Equal(t, 0, c.Len())
+		require.Panics(t, func() {
+			c.Pop()
+		})
+
+		files, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		info, err := files[0].Info()
+		require.NoError(t, err)
+		require.Zero(t, info.Size())
+
+		require.NoError(t, c.Close())
+		files, err = os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Empty(t, files)
+	})
+	t.Run("custom_codec", func(t *testing.T) {
+		t.Parallel()
+
+		c := NewSpilling[uint64](64, t.TempDir(), uint64Codec{})
+		defer c.Close()
+		for n := uint64(0); n < 1000; n++ {
+			c.Push(n)
+		}
+		require.Equal(t, 960, c.Spilled())
+		for n := uint64(1000); n > 0; n-- {
+			require.Equal(t, n-1, c.Pop())
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package stack
+
+import "github.com/iv-menshenin/fusion/errors"
+
+// PushStack moves all the elements of `other` on top of the stack, leaving `other` empty.
+//
+// The buckets of `other` are relinked to the chain of the stack instead of being copied,
+// so the cost depends only on the count of buckets.
+func (c *Stack[T]) PushStack(other *Stack[T]) {
+	if other == c || other.count == 0 {
+		return
+	}
+	if c.last != nil && c.last.count == 0 {
+		c.dropLastBucket()
+	}
+	for i, b := range other.dir {
+		if dead := other.lo - b.base; i == 0 && dead > 0 {
+			// the evicted cells can be kept only in the bottom bucket, so the live elements are moved down
+			b.compact(dead)
+		}
+		if b.count == 0 {
+			continue
+		}
+		b.base, b.prev = c.count+c.lo, c.last
+		c.last = b
+		c.dir = append(c.dir, b)
+		c.count += b.count
+	}
+	other.count = 0
+	other.last = nil
+	other.dir = nil
+}
+
+// SplitTop detaches the top `n` elements from the stack and returns them as a new Stack with the same options.
+//
+// Whole buckets are relinked without copying. The bucket where the split happens is divided in two parts
+// that share the same memory, so no elements are copied at all.
+func (c *Stack[T]) SplitTop(n int) *Stack[T] {
+	if n < 0 || n > c.count {
+		panic(errors.OutOfBounds(c.count, n))
+	}
+	s := &Stack[T]{opts: c.opts}
+	if n == 0 {
+		return s
+	}
+	var (
+		from  = c.count - n
+		bId   = c.searchBucket(from)
+		b     = c.dir[bId]
+		moved = c.dir[bId:]
+		kept  = bId
+	)
+	if p := from + c.lo - b.base; p > 0 {
+		nb := &bucket[T]{
+			count: b.count - p,
+			cont:  b.cont[p:cap(b.cont)],
+		}
+		b.cont = b.cont[:p:p]
+		b.count = p
+		moved = append([]*bucket[T]{nb}, c.dir[bId+1:]...)
+		kept++
+	}
+	for _, mb := range moved {
+		if mb.count == 0 {
+			continue
+		}
+		mb.base, mb.prev = s.count, s.last
+		s.last = mb
+		s.dir = append(s.dir, mb)
+		s.count += mb.count
+	}
+	for x := kept; x < len(c.dir); x++ {
+		c.dir[x] = nil
+	}
+	c.dir = c.dir[:kept]
+	c.count = from
+	c.last = nil
+	if kept > 0 {
+		c.last = c.dir[kept-1]
+	}
+	c.resetEmpty()
+	return s
+}
+
+// compact moves the elements of the bucket down by `dead` cells and clears the released cells.
+func (b *bucket[T]) compact(dead int) {
+	copy(b.cont, b.cont[dead:b.count])
+	var empty T
+	for x := b.count - dead; x < b.count; x++ {
+		b.cont[x] = empty
+	}
+	b.count -= dead
+	b.base += dead
+}
@@ -0,0 +1,112 @@
+package stack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushStack(t *testing.T) {
+	t.Parallel()
+	t.Run("relink", func(t *testing.T) {
+		t.Parallel()
+
+		var a, b Stack[int]
+		for n := 0; n < 1500; n++ {
+			a.Push(n)
+		}
+		for n := 1500; n < 5000; n++ {
+			b.Push(n)
+		}
+		a.PushStack(&b)
+		require.Equal(t, 0, b.Len())
+		require.Equal(t, 5000, a.Len())
+		for n := 0; n < 5000; n++ {
+			require.Equal(t, n, *a.Get(n))
+		}
+		require.Equal(t, 4999, *a.Peek())
+
+		// both are still usable
+		b.Push(-1)
+		require.Equal(t, -1, *b.Peek())
+		for n := 5000; n < 10_000; n++ {
+			a.Push(n)
+		}
+		for n := 9999; n >= 0; n-- {
+			require.Equal(t, n, a.Pop())
+		}
+	})
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+
+		var a, b Stack[int]
+		a.Push(1)
+		a.Pop()
+		b.Push(2)
+		a.PushStack(&b)
+		require.Equal(t, 1, a.Len())
+		require.Equal(t, 2, *a.Get(0))
+		a.PushStack(&b)
+		require.Equal(t, 1, a.Len())
+	})
+}
+
+func TestSplitTop(t *testing.T) {
+	t.Parallel()
+	t.Run("mid_bucket", func(t *testing.T) {
+		t.Parallel()
+
+		var c Stack[int]
+		for n := 0; n < 5000; n++ {
+			c.Push(n)
+		}
+		top := c.SplitTop(2500)
+		require.Equal(t, 2500, c.Len())
+		require.Equal(t, 2500, top.Len())
+		for n := 0; n < 2500; n++ {
+			require.Equal(t, n, *c.Get(n))
+			require.Equal(t, n+2500, *top.Get(n))
+		}
+
+		// the halves of the split bucket do not overwrite each other
+		for n := 0; n < 1000; n++ {
+			c.Push(-n)
+			top.Push(n + 5000)
+		}
+		for n := 0; n < 3500; n++ {
+			require.Equal(t, n+2500, *top.Get(n))
+		}
+		for n := 999; n >= 0; n-- {
+			require.Equal(t, -n, c.Pop())
+		}
+		require.Equal(t, 2499, *c.Peek())
+	})
+	t.Run("boundaries", func(t *testing.T) {
+		t.Parallel()
+
+		var c Stack[int]
+		for n := 0; n < 3000; n++ {
+			c.Push(n)
+		}
+		require.Equal(t, 0, c.SplitTop(0).Len())
+
+		top := c.SplitTop(2000)
+		require.Len(t, top.dir, 1)
+		require.Len(t, c.dir, 1)
+		require.Equal(t, 2000, top.Len())
+
+		all := c.SplitTop(1000)
+		require.Equal(t, 0, c.Len())
+		require.Equal(t, 1000, all.Len())
+		c.Push(42)
+		require.Equal(t, 42, *c.Peek())
+
+		all.PushStack(top)
+		for n := 0; n < 3000; n++ {
+			require.Equal(t, n, *all.Get(n))
+		}
+		require.Panics(t, func() {
+			all.SplitTop(3001)
+		})
+	})
+}
@@ -4,15 +4,18 @@ import "github.com/iv-menshenin/fusion/errors"
 
 type (
 	Stack[T any] struct {
-		count int
-		last  *bucket[T]
-		cache []bucket[T]
+		count  int
+		last   *bucket[T]
+		cache  []bucket[T]
+		cached int // total capacity of the cached buckets
+		opts   *Options
+		lo     int // count of elements evicted from the bottom
 
-		// search cache
-		sclx  int
-		sccur *bucket[T]
+		// bucket directory from the bottom to the top, for random access
+		dir []*bucket[T]
 	}
 	bucket[T any] struct {
+		base  int // position of the first element, including the elements evicted from the bottom
 		count int
 		cont  []T
 		prev  *bucket[T]
@@ -27,6 +30,7 @@ func Init[T any](val []T) *Stack[T] {
 			cont:  val,
 		},
 	}
+	s.dir = append(s.dir, s.last)
 	return &s
 }
 
@@ -42,26 +46,35 @@ func (c *Stack[T]) Peek() *T {
 	return &b.cont[b.count-1]
 }
 
-// Get returns the top element of the stack without removing it. It's copy of Peek method for sorting support.
+// Get returns the element located at the position `i` counting from the bottom of the stack without removing it.
+// It allows sorting the stack with the fsort package.
+//
+// The bucket is found with the bucket directory, so random access takes O(log buckets) and the top bucket is reached
+// in O(1).
 func (c *Stack[T]) Get(i int) *T {
-	if i > c.count-1 {
+	if i < 0 || i > c.count-1 {
 		panic(errors.OutOfBounds(c.count, i))
 	}
-	var (
-		lx  = c.count
-		cur = c.last
-	)
-	if c.sccur != nil && c.sclx-c.sccur.count >= i {
-		lx = c.sclx
-		cur = c.sccur
+	b := c.last
+	if i+c.lo < b.base {
+		b = c.dir[c.searchBucket(i)]
 	}
-	for lx-cur.count > i {
-		lx -= cur.count
-		cur = cur.prev
+	return &b.cont[i+c.lo-b.base]
+}
+
+// searchBucket returns the position in the directory of the bucket containing the element `i`.
+func (c *Stack[T]) searchBucket(i int) int {
+	i += c.lo
+	lo, hi := 0, len(c.dir)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if c.dir[mid].base > i {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
 	}
-	c.sclx = lx
-	c.sccur = cur
-	return &cur.cont[cur.count-(lx-i)]
+	return lo - 1
 }
 
 // Pop removes and returns the top element of the stack.
@@ -69,15 +82,14 @@ func (c *Stack[T]) Pop() T {
 	if c.count == 0 {
 		panic(errors.OutOfBounds(c.count, 0))
 	}
-	if c.sccur != nil {
-		c.sccur = nil
-	}
 	if c.last.count == 0 {
 		c.dropLastBucket()
 	}
 	c.count--
 	c.last.count--
-	return c.last.cont[c.last.count]
+	val := c.last.cont[c.last.count]
+	c.resetEmpty()
+	return val
 }
 
 // Push adds an element to the top of the stack.
@@ -85,9 +97,6 @@ func (c *Stack[T]) Push(elem T) *T {
 	if !c.capable() {
 		c.extend()
 	}
-	if c.sccur == c.last {
-		c.sccur = nil
-	}
 	l := c.last.count
 	c.last.count++
 	c.count++
@@ -95,6 +104,72 @@ func (c *Stack[T]) Push(elem T) *T {
 	return &c.last.cont[l]
 }
 
+// PushSlice adds all the elements of the slice to the top of the stack, the last element of the slice becomes the top.
+// The elements are copied into the top bucket, and new buckets are added as needed.
+func (c *Stack[T]) PushSlice(elems []T) {
+	for len(elems) > 0 {
+		if !c.capable() {
+			c.extend()
+		}
+		n := copy(c.last.cont[c.last.count:cap(c.last.cont)], elems)
+		c.last.count += n
+		c.count += n
+		elems = elems[n:]
+	}
+}
+
+// PopN removes `n` elements from the top of the stack and appends them to `dst` in the order they were pushed,
+// so the former top element becomes the last one. It returns the extended slice.
+func (c *Stack[T]) PopN(n int, dst []T) []T {
+	if n < 0 || n > c.count {
+		panic(errors.OutOfBounds(c.count, n))
+	}
+	if n == 0 {
+		return dst
+	}
+	from := c.count - n
+	for _, b := range c.dir[c.searchBucket(from):] {
+		x := from + c.lo - b.base
+		if x < 0 {
+			x = 0
+		}
+		dst = append(dst, b.cont[x:b.count]...)
+	}
+	c.Truncate(from)
+	return dst
+}
+
+// Truncate removes the elements from the top of the stack until only `n` elements are left.
+// Emptied buckets go to the cache for reuse, and the released cells are cleared.
+func (c *Stack[T]) Truncate(n int) {
+	if n < 0 || n > c.count {
+		panic(errors.OutOfBounds(c.count, n))
+	}
+	var empty T
+	for c.count > n || (c.last != nil && c.last.count == 0 && c.last.prev != nil) {
+		b := c.last
+		keep := n + c.lo - b.base
+		if keep < 0 {
+			keep = 0
+		}
+		for x := keep; x < b.count; x++ {
+			b.cont[x] = empty
+		}
+		c.count -= b.count - keep
+		b.count = keep
+		if b.count == 0 && b.prev != nil {
+			c.dropLastBucket()
+		}
+	}
+	c.resetEmpty()
+}
+
+// Clear removes all the elements from the stack. Emptied buckets go to the cache for reuse,
+// and the released cells are cleared.
+func (c *Stack[T]) Clear() {
+	c.Truncate(0)
+}
+
 func (c *Stack[T]) Len() int {
 	return c.count
 }
@@ -107,24 +182,25 @@ func (c *Stack[T]) capable() bool {
 }
 
 func (c *Stack[T]) extend() {
-	if c.last == nil {
-		c.last = c.newBucket()
-		return
-	}
 	n := c.newBucket()
-	n.prev = c.last
+	n.base, n.prev = c.lo, c.last
+	if c.last != nil {
+		n.base = c.last.base + c.last.count
+	}
 	c.last = n
+	c.dir = append(c.dir, n)
 }
 
 const (
 	bucketsCache  = 32
 	firstBucketSz = 1_000
 	maxBucketSz   = 1_000_000
+	growthFactor  = 2
 )
 
 func (c *Stack[T]) newBucket() *bucket[T] {
 	if len(c.cache) == 0 {
-		c.cache = make([]bucket[T], bucketsCache)
+		c.cache = make([]bucket[T], c.options().CacheLimit)
 	}
 	var (
 		l = len(c.cache) - 1
@@ -132,18 +208,24 @@ func (c *Stack[T]) newBucket() *bucket[T] {
 	)
 	if cap(b.cont) == 0 {
 		b.cont = make([]T, c.newSZ())
+	} else {
+		c.cached -= cap(b.cont)
 	}
 	c.cache = c.cache[:l]
 	return b
 }
 
 func (c *Stack[T]) newSZ() int {
+	o := c.options()
 	if c.last == nil {
-		return firstBucketSz
+		return o.InitialBucketSize
 	}
-	var sz = cap(c.last.cont) * 2
-	if sz > maxBucketSz {
-		sz = maxBucketSz
+	var sz = int(float64(cap(c.last.cont)) * o.GrowthFactor)
+	if sz > o.MaxBucketSize {
+		sz = o.MaxBucketSize
+	}
+	if sz < o.InitialBucketSize {
+		sz = o.InitialBucketSize
 	}
 	return sz
 }
@@ -157,12 +239,41 @@ func (c *Stack[T]) dropLastBucket() {
 	}
 	removed := c.last
 	c.last = c.last.prev
-	// keep already allocated cont in cache
+	c.dir[len(c.dir)-1] = nil
+	c.dir = c.dir[:len(c.dir)-1]
+	c.cacheBucket(removed)
+}
+
+// cacheBucket keeps the already allocated cont of the removed bucket for reuse.
+func (c *Stack[T]) cacheBucket(removed *bucket[T]) {
+	if len(c.cache) == 0 {
+		c.cache = make([]bucket[T], c.options().CacheLimit)
+	}
 	for i := len(c.cache); i > 0; {
 		i--
 		if c.cache[i].cont == nil {
 			c.cache[i] = *removed
+			c.cached += cap(removed.cont)
 			break
 		}
 	}
+	if ratio := c.options().ShrinkRatio; ratio > 0 && c.count*ratio < c.capacity()+c.cached {
+		c.releaseCache()
+	}
+}
+
+// capacity returns the count of elements that fit into the live buckets.
+func (c *Stack[T]) capacity() int {
+	if c.last == nil {
+		return 0
+	}
+	return c.last.base - c.lo + cap(c.last.cont)
+}
+
+// releaseCache drops the cached buckets, so their memory can be collected.
+func (c *Stack[T]) releaseCache() {
+	for i := range c.cache {
+		c.cache[i] = bucket[T]{}
+	}
+	c.cached = 0
 }
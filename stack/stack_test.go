@@ -1,6 +1,7 @@
 package stack
 
 import (
+	"math/rand"
 	"strconv"
 	"testing"
 
@@ -131,6 +132,33 @@ func TestStressStack(t *testing.T) {
 	}
 }
 
+func TestStackRandomAccess(t *testing.T) {
+	t.Parallel()
+	var c Stack[int]
+	const elemCount = 100_000
+	for n := 0; n < elemCount; n++ {
+		c.Push(n)
+	}
+	// shrink and grow again to reuse the cached buckets
+	for n := 0; n < elemCount/2; n++ {
+		c.Pop()
+	}
+	for n := elemCount / 2; n < elemCount; n++ {
+		c.Push(n)
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for n := 0; n < 10_000; n++ {
+		i := rnd.Intn(elemCount)
+		require.Equal(t, i, *c.Get(i))
+	}
+	require.Panics(t, func() {
+		c.Get(-1)
+	})
+	require.Panics(t, func() {
+		c.Get(elemCount)
+	})
+}
+
 func TestStackPushPop(t *testing.T) {
 	t.Parallel()
 	t.Run("push", func(t *testing.T) {
@@ -180,6 +208,94 @@ func TestStackPushPop(t *testing.T) {
 	})
 }
 
+func TestStackBulk(t *testing.T) {
+	t.Parallel()
+	t.Run("push_slice", func(t *testing.T) {
+		t.Parallel()
+
+		var c Stack[int]
+		c.Push(-1)
+		elems := make([]int, 5000)
+		for n := range elems {
+			elems[n] = n
+		}
+		c.PushSlice(elems)
+		require.Equal(t, 5001, c.Len())
+		require.Equal(t, 4999, *c.Peek())
+		for n := 0; n < 5000; n++ {
+			require.Equal(t, n, *c.Get(n + 1))
+		}
+		require.Equal(t, 4999, c.Pop())
+	})
+	t.Run("pop_n", func(t *testing.T) {
+		t.Parallel()
+
+		var c Stack[int]
+		for n := 0; n < 5000; n++ {
+			c.Push(n)
+		}
+		got := c.PopN(3500, []int{-1})
+		require.Len(t, got, 3501)
+		require.Equal(t, -1, got[0])
+		require.Equal(t, 1500, got[1])
+		require.Equal(t, 4999, got[3500])
+		require.Equal(t, 1500, c.Len())
+		require.Equal(t, 1499, *c.Peek())
+		require.Len(t, c.dir, 2)
+
+		require.Empty(t, c.PopN(0, nil))
+		require.Panics(t, func() {
+			c.PopN(1501, nil)
+		})
+	})
+	t.Run("truncate_clear", func(t *testing.T) {
+		t.Parallel()
+
+		var c Stack[*int]
+		for n := 0; n < 10_000; n++ {
+			v := n
+			c.Push(&v)
+		}
+		buckets := len(c.dir)
+		c.Truncate(1000)
+		require.Equal(t, 1000, c.Len())
+		require.Len(t, c.dir, 1)
+		require.Equal(t, 999, **c.Peek())
+		// released cells are cleared
+		require.Nil(t, c.cache[len(c.cache)-1].cont[0])
+
+		// cached buckets are reused
+		for n := 1000; n < 10_000; n++ {
+			v := n
+			c.Push(&v)
+		}
+		require.LessOrEqual(t, len(c.dir), buckets)
+		for n := 0; n < 10_000; n++ {
+			require.Equal(t, n, **c.Get(n))
+		}
+
+		c.Clear()
+		require.Equal(t, 0, c.Len())
+		require.Len(t, c.dir, 1)
+		require.Nil(t, c.dir[0].cont[0])
+		v := 7
+		c.Push(&v)
+		require.Equal(t, 7, **c.Peek())
+	})
+}
+
+func TestStackBulkAllocs(t *testing.T) {
+	var c Stack[int]
+	elems := make([]int, 10_000)
+	c.PushSlice(elems)
+	c.Clear()
+	allocs := testing.AllocsPerRun(100, func() {
+		c.PushSlice(elems)
+		elems = c.PopN(len(elems), elems[:0])
+	})
+	require.Zero(t, allocs)
+}
+
 func BenchmarkStackTravel(b *testing.B) {
 	type Elem struct {
 		s          string
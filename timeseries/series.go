@@ -0,0 +1,288 @@
+package timeseries
+
+import "time"
+
+type (
+	// Series is a Collection of timestamped samples which are kept ordered by time.
+	//
+	// Like Collection it stores data in a chain of buckets, and each bucket keeps its own timestamps, so range queries
+	// use binary search first over the buckets and then inside one of them. Expired samples are dropped from the front
+	// by whole buckets, without moving the rest of the data.
+	//
+	// Samples are usually appended in chronological order, which is the cheapest case. A sample that arrives late
+	// is inserted into its position, which moves the tail of a single bucket.
+	//
+	// The Push method returns a reference to the stored value. Note that the reference is guaranteed to be valid
+	// only until the first call to methods that modify the Series. Avoid storing the reference for a long time.
+	Series[T any] struct {
+		len       int
+		bsz       int
+		retention time.Duration
+		buckets   []*bucket[T]
+	}
+	bucket[T any] struct {
+		ts   []int64
+		data []T
+	}
+)
+
+const defaultBucketSz = 1024
+
+// New creates a new Series with the specified bucket size. If the size is zero, the default value will be used.
+//
+// The retention defines the time window used by the Expire method, zero retention means that data never expires.
+func New[T any](bucketSz int, retention time.Duration) *Series[T] {
+	if bucketSz < 2 {
+		bucketSz = defaultBucketSz
+	}
+	return &Series[T]{
+		bsz:       bucketSz,
+		retention: retention,
+	}
+}
+
+func (s *Series[T]) Len() int {
+	return s.len
+}
+
+// First returns the timestamp of the oldest sample. It returns the zero time if the Series is empty.
+func (s *Series[T]) First() time.Time {
+	if s.len == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, s.buckets[0].ts[0])
+}
+
+// Last returns the timestamp of the newest sample. It returns the zero time if the Series is empty.
+func (s *Series[T]) Last() time.Time {
+	if s.len == 0 {
+		return time.Time{}
+	}
+	b := s.buckets[len(s.buckets)-1]
+	return time.Unix(0, b.ts[len(b.ts)-1])
+}
+
+// Push adds a new sample to the Series and returns a reference to its value.
+// Samples with equal timestamps are kept in the order they were added.
+func (s *Series[T]) Push(at time.Time, val T) *T {
+	if s.bsz == 0 {
+		s.bsz = defaultBucketSz
+	}
+	ts := at.UnixNano()
+	if len(s.buckets) == 0 {
+		s.extendBuckets()
+	}
+	bId := len(s.buckets) - 1
+	if b := s.buckets[bId]; len(b.ts) > 0 && b.ts[len(b.ts)-1] > ts {
+		// a late sample
+		bId = s.searchBucket(ts)
+	}
+	b := s.buckets[bId]
+	if len(b.ts) == cap(b.ts) {
+		if bId == len(s.buckets)-1 && (len(b.ts) == 0 || b.ts[len(b.ts)-1] <= ts) {
+			s.extendBuckets()
+			bId++
+		} else if len(b.ts) < s.bsz {
+			// the front bucket trimmed by DropBefore has lost a part of its capacity, it is not really full
+			b.regrow(s.bsz)
+		} else {
+			s.split(bId)
+			if ts >= s.buckets[bId+1].ts[0] {
+				bId++
+			}
+		}
+		b = s.buckets[bId]
+	}
+	xId := searchRight(b.ts, ts)
+	var empty T
+	b.ts = append(b.ts, 0)
+	b.data = append(b.data, empty)
+	copy(b.ts[xId+1:], b.ts[xId:])
+	copy(b.data[xId+1:], b.data[xId:])
+	b.ts[xId] = ts
+	b.data[xId] = val
+	s.len++
+	return &b.data[xId]
+}
+
+func (s *Series[T]) extendBuckets() {
+	s.buckets = append(s.buckets, &bucket[T]{
+		ts:   make([]int64, 0, s.bsz),
+		data: make([]T, 0, s.bsz),
+	})
+}
+
+// regrow moves the data of the bucket to new slices with the full capacity.
+func (b *bucket[T]) regrow(bsz int) {
+	ts := make([]int64, len(b.ts), bsz)
+	data := make([]T, len(b.data), bsz)
+	copy(ts, b.ts)
+	copy(data, b.data)
+	b.ts, b.data = ts, data
+}
+
+// split moves the upper half of the bucket to a new bucket placed next to it.
+func (s *Series[T]) split(bId int) {
+	b := s.buckets[bId]
+	half := len(b.ts) / 2
+	n := &bucket[T]{
+		ts:   make([]int64, len(b.ts)-half, s.bsz),
+		data: make([]T, len(b.ts)-half, s.bsz),
+	}
+	copy(n.ts, b.ts[half:])
+	copy(n.data, b.data[half:])
+	var empty T
+	for x := half; x < len(b.data); x++ {
+		b.data[x] = empty
+	}
+	b.ts = b.ts[:half]
+	b.data = b.data[:half]
+
+	s.buckets = append(s.buckets, nil)
+	copy(s.buckets[bId+2:], s.buckets[bId+1:])
+	s.buckets[bId+1] = n
+}
+
+// searchBucket returns the last bucket that starts not later than `ts`, or the first bucket if there is none.
+func (s *Series[T]) searchBucket(ts int64) int {
+	lo, hi := 0, len(s.buckets)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if s.buckets[mid].ts[0] > ts {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	if lo > 0 {
+		lo--
+	}
+	return lo
+}
+
+// lowerBucket returns the first bucket that ends not earlier than `ts`, or the count of buckets if there is none.
+// Unlike searchBucket, it finds the earliest of the samples with equal timestamps spread over several buckets.
+func (s *Series[T]) lowerBucket(ts int64) int {
+	lo, hi := 0, len(s.buckets)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if b := s.buckets[mid]; b.ts[len(b.ts)-1] < ts {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// Range iterates through the samples with timestamps within [from, to) in chronological order and calls
+// the provided callback function for each of them. If the callback function returns false, the iteration will be stopped.
+func (s *Series[T]) Range(from, to time.Time, callback func(at time.Time, val *T) bool) {
+	s.rangeNano(from.UnixNano(), to.UnixNano(), func(ts int64, val *T) bool {
+		return callback(time.Unix(0, ts), val)
+	})
+}
+
+func (s *Series[T]) rangeNano(from, to int64, callback func(ts int64, val *T) bool) {
+	if s.len == 0 {
+		return
+	}
+	bId := s.lowerBucket(from)
+	if bId == len(s.buckets) {
+		return
+	}
+	xId := searchLeft(s.buckets[bId].ts, from)
+	for ; bId < len(s.buckets); bId++ {
+		b := s.buckets[bId]
+		for ; xId < len(b.ts); xId++ {
+			if b.ts[xId] >= to {
+				return
+			}
+			if !callback(b.ts[xId], &b.data[xId]) {
+				return
+			}
+		}
+		xId = 0
+	}
+}
+
+// Each iterates through all the samples in chronological order and calls the provided callback function for each
+// of them. If the callback function returns false, the iteration will be stopped.
+func (s *Series[T]) Each(callback func(at time.Time, val *T) bool) {
+	for _, b := range s.buckets {
+		for x := range b.ts {
+			if !callback(time.Unix(0, b.ts[x]), &b.data[x]) {
+				return
+			}
+		}
+	}
+}
+
+// DropBefore removes all the samples older than `t` and returns the count of removed samples.
+//
+// Buckets that are expired entirely are released without touching their data, only the boundary bucket is trimmed.
+func (s *Series[T]) DropBefore(t time.Time) int {
+	ts := t.UnixNano()
+	var (
+		dropped int
+		bId     int
+	)
+	for ; bId < len(s.buckets); bId++ {
+		b := s.buckets[bId]
+		if b.ts[len(b.ts)-1] >= ts {
+			break
+		}
+		dropped += len(b.ts)
+		s.buckets[bId] = nil
+	}
+	s.buckets = s.buckets[bId:]
+	if len(s.buckets) > 0 {
+		b := s.buckets[0]
+		xId := searchLeft(b.ts, ts)
+		// clear cells
+		var empty T
+		for x := 0; x < xId; x++ {
+			b.data[x] = empty
+		}
+		b.ts = b.ts[xId:]
+		b.data = b.data[xId:]
+		dropped += xId
+	}
+	s.len -= dropped
+	return dropped
+}
+
+// Expire removes the samples that are older than the retention window measured back from `now`
+// and returns the count of removed samples.
+func (s *Series[T]) Expire(now time.Time) int {
+	if s.retention <= 0 {
+		return 0
+	}
+	return s.DropBefore(now.Add(-s.retention))
+}
+
+func searchLeft(ts []int64, val int64) int {
+	lo, hi := 0, len(ts)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if ts[mid] < val {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+func searchRight(ts []int64, val int64) int {
+	lo, hi := 0, len(ts)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if ts[mid] > val {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo
+}
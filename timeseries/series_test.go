@@ -0,0 +1,163 @@
+package timeseries
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestSeries(t *testing.T) {
+	t.Parallel()
+	t.Run("push_range", func(t *testing.T) {
+		t.Parallel()
+
+		s := New[int](4, 0)
+		for n := 0; n < 100; n++ {
+			s.Push(epoch.Add(time.Duration(n)*time.Second), n)
+		}
+		require.Equal(t, 100, s.Len())
+		require.True(t, epoch.Equal(s.First()))
+		require.True(t, epoch.Add(99*time.Second).Equal(s.Last()))
+
+		var got []int
+		s.Range(epoch.Add(10*time.Second), epoch.Add(15*time.Second), func(at time.Time, val *int) bool {
+			require.True(t, epoch.Add(time.Duration(*val)*time.Second).Equal(at))
+			got = append(got, *val)
+			return true
+		})
+		require.Equal(t, []int{10, 11, 12, 13, 14}, got)
+	})
+	t.Run("late_samples", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			s        = New[int](8, 0)
+			rnd      = rand.New(rand.NewSource(7))
+			expected []int
+		)
+		for n := 0; n < 1000; n++ {
+			v := rnd.Intn(10000)
+			s.Push(epoch.Add(time.Duration(v)*time.Millisecond), v)
+			expected = append(expected, v)
+		}
+		sort.Ints(expected)
+
+		var got []int
+		s.Each(func(at time.Time, val *int) bool {
+			got = append(got, *val)
+			return true
+		})
+		require.Equal(t, expected, got)
+	})
+	t.Run("drop_before", func(t *testing.T) {
+		t.Parallel()
+
+		s := New[int](10, time.Minute)
+		for n := 0; n < 300; n++ {
+			s.Push(epoch.Add(time.Duration(n)*time.Second), n)
+		}
+		require.Equal(t, 0, s.Expire(epoch.Add(30*time.Second)))
+		require.Equal(t, 125, s.Expire(epoch.Add(185*time.Second)))
+		require.Equal(t, 175, s.Len())
+		require.True(t, epoch.Add(125*time.Second).Equal(s.First()))
+		require.Len(t, s.buckets, 18)
+
+		var got []int
+		s.Range(epoch, epoch.Add(128*time.Second), func(at time.Time, val *int) bool {
+			got = append(got, *val)
+			return true
+		})
+		require.Equal(t, []int{125, 126, 127}, got)
+
+		require.Equal(t, 175, s.DropBefore(epoch.Add(time.Hour)))
+		require.Equal(t, 0, s.Len())
+		require.Len(t, s.buckets, 0)
+
+		s.Push(epoch, 1)
+		require.Equal(t, 1, s.Len())
+	})
+	t.Run("late_sample_after_drop", func(t *testing.T) {
+		t.Parallel()
+
+		s := New[int](4, 0)
+		for n := 1; n <= 8; n++ {
+			s.Push(epoch.Add(time.Duration(n)*time.Second), n)
+		}
+		// the front bucket is trimmed to a single sample
+		require.Equal(t, 3, s.DropBefore(epoch.Add(4*time.Second)))
+		s.Push(epoch.Add(4500*time.Millisecond), 45)
+		require.True(t, epoch.Add(4*time.Second).Equal(s.First()))
+		for _, b := range s.buckets {
+			require.NotEmpty(t, b.ts)
+		}
+
+		var got []int
+		s.Each(func(_ time.Time, val *int) bool {
+			got = append(got, *val)
+			return true
+		})
+		require.Equal(t, []int{4, 45, 5, 6, 7, 8}, got)
+		require.Equal(t, 1, s.DropBefore(epoch.Add(4500*time.Millisecond)))
+		require.Equal(t, 5, s.Len())
+	})
+	t.Run("equal_timestamps", func(t *testing.T) {
+		t.Parallel()
+
+		s := New[int](2, 0)
+		s.Push(epoch.Add(-time.Second), -1)
+		for n := 0; n < 5; n++ {
+			s.Push(epoch, n)
+		}
+		s.Push(epoch.Add(time.Second), 5)
+
+		// the samples at the epoch are spread over several buckets
+		var got []int
+		s.Range(epoch, epoch.Add(time.Second), func(_ time.Time, val *int) bool {
+			got = append(got, *val)
+			return true
+		})
+		require.Equal(t, []int{0, 1, 2, 3, 4}, got)
+
+		w := Tumbling(s, epoch, epoch.Add(2*time.Second), time.Second, func(acc int, _ *int) int {
+			return acc + 1
+		})
+		require.Equal(t, 5, w[0].Value)
+		require.Equal(t, 1, w[1].Value)
+
+		got = got[:0]
+		s.Range(epoch.Add(2*time.Second), epoch.Add(3*time.Second), func(_ time.Time, val *int) bool {
+			got = append(got, *val)
+			return true
+		})
+		require.Empty(t, got)
+	})
+}
+
+func BenchmarkSeries(b *testing.B) {
+	b.Run("Push", func(b *testing.B) {
+		b.ReportAllocs()
+		s := New[int](0, 0)
+		for n := 0; n < b.N; n++ {
+			s.Push(epoch.Add(time.Duration(n)), n)
+		}
+	})
+	b.Run("Range", func(b *testing.B) {
+		s := New[int](0, 0)
+		for n := 0; n < 1_000_000; n++ {
+			s.Push(epoch.Add(time.Duration(n)*time.Millisecond), n)
+		}
+		b.ResetTimer()
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			from := epoch.Add(time.Duration(n%1_000_000) * time.Millisecond)
+			s.Range(from, from.Add(100*time.Millisecond), func(time.Time, *int) bool {
+				return true
+			})
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package timeseries
+
+import "time"
+
+// Window is the result of an aggregation over the samples within [Start, End).
+type Window[A any] struct {
+	Start time.Time
+	End   time.Time
+	Count int
+	Value A
+}
+
+// Tumbling splits the time range [from, to) into consecutive non-overlapping windows of the given width and
+// aggregates the samples of each window with the `fold` function, starting from the zero value of A.
+//
+// The data is traversed only once. Windows without samples are also returned, their Count is zero.
+func Tumbling[T, A any](s *Series[T], from, to time.Time, width time.Duration, fold func(acc A, val *T) A) []Window[A] {
+	if width <= 0 || !from.Before(to) {
+		return nil
+	}
+	var (
+		start  = from.UnixNano()
+		result = makeWindows[A](from, to, width, width)
+	)
+	s.rangeNano(start, to.UnixNano(), func(ts int64, val *T) bool {
+		w := &result[(ts-start)/int64(width)]
+		w.Value = fold(w.Value, val)
+		w.Count++
+		return true
+	})
+	return result
+}
+
+// Sliding aggregates the samples within the windows of the given width, each next window starts `step` later
+// than the previous one. The first window starts at `from`, the last one starts before `to`.
+//
+// Overlapping windows traverse the shared samples again, so keep the width/step ratio reasonable.
+func Sliding[T, A any](s *Series[T], from, to time.Time, width, step time.Duration, fold func(acc A, val *T) A) []Window[A] {
+	if width <= 0 || step <= 0 || !from.Before(to) {
+		return nil
+	}
+	result := makeWindows[A](from, to, width, step)
+	for n := range result {
+		w := &result[n]
+		s.rangeNano(w.Start.UnixNano(), w.End.UnixNano(), func(_ int64, val *T) bool {
+			w.Value = fold(w.Value, val)
+			w.Count++
+			return true
+		})
+	}
+	return result
+}
+
+func makeWindows[A any](from, to time.Time, width, step time.Duration) []Window[A] {
+	var result = make([]Window[A], 0, 1+int(to.Sub(from)/step))
+	for start := from; start.Before(to); start = start.Add(step) {
+		result = append(result, Window[A]{
+			Start: start,
+			End:   start.Add(width),
+		})
+	}
+	return result
+}
@@ -0,0 +1,49 @@
+package timeseries
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindows(t *testing.T) {
+	t.Parallel()
+	s := New[int](16, 0)
+	for n := 0; n < 60; n++ {
+		s.Push(epoch.Add(time.Duration(n)*time.Second), n)
+	}
+	sum := func(acc int, val *int) int {
+		return acc + *val
+	}
+	t.Run("tumbling", func(t *testing.T) {
+		t.Parallel()
+
+		w := Tumbling(s, epoch, epoch.Add(time.Minute), 20*time.Second, sum)
+		require.Len(t, w, 3)
+		require.Equal(t, epoch.Add(20*time.Second), w[1].Start)
+		require.Equal(t, epoch.Add(40*time.Second), w[1].End)
+		require.Equal(t, 20, w[1].Count)
+		require.Equal(t, 190, w[0].Value)
+		require.Equal(t, 590, w[1].Value)
+		require.Equal(t, 990, w[2].Value)
+	})
+	t.Run("tumbling_empty", func(t *testing.T) {
+		t.Parallel()
+
+		w := Tumbling(s, epoch.Add(50*time.Second), epoch.Add(90*time.Second), 20*time.Second, sum)
+		require.Len(t, w, 2)
+		require.Equal(t, 10, w[0].Count)
+		require.Equal(t, 0, w[1].Count)
+	})
+	t.Run("sliding", func(t *testing.T) {
+		t.Parallel()
+
+		w := Sliding(s, epoch, epoch.Add(30*time.Second), 20*time.Second, 10*time.Second, sum)
+		require.Len(t, w, 3)
+		require.Equal(t, 190, w[0].Value)
+		require.Equal(t, 390, w[1].Value)
+		require.Equal(t, 590, w[2].Value)
+		require.Equal(t, 20, w[2].Count)
+	})
+}
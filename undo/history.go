@@ -0,0 +1,223 @@
+package undo
+
+import (
+	"sort"
+
+	"github.com/iv-menshenin/fusion/stack"
+)
+
+// History keeps the undo and redo stacks of the commands of type C.
+//
+// The commands are applied and reverted by the user-provided functions. Several commands can be grouped
+// into a transaction, which is undone and redone as a whole.
+//
+// When the max depth is set, the undo stack is limited by evicting its oldest bucket, so the depth of the history
+// varies between max depth minus the bucket size and max depth. A transaction is never split by the eviction:
+// the rest of a partially evicted transaction is evicted too.
+type History[C any] struct {
+	apply  func(C)
+	revert func(C)
+
+	maxDepth int
+	undo     *stack.Stack[entry[C]]
+	redo     *stack.Stack[entry[C]]
+
+	group   uint64 // the last group ID given out
+	open    uint64 // the group of the open transaction
+	depth   int    // nesting depth of the open transaction
+	evicted bool   // the oldest commands were evicted, so the initial state can not be reached
+}
+
+type entry[C any] struct {
+	group uint64
+	cmd   C
+}
+
+// Savepoint marks a state of the history, see Savepoint method.
+type Savepoint uint64
+
+// New creates a new History. The `apply` function is called when a command is done or redone,
+// the `revert` function is called when a command is undone. Zero max depth means unlimited history.
+func New[C any](maxDepth int, apply, revert func(C)) *History[C] {
+	h := History[C]{
+		apply:    apply,
+		revert:   revert,
+		maxDepth: maxDepth,
+		redo:     &stack.Stack[entry[C]]{},
+	}
+	if maxDepth > 0 {
+		bsz := maxDepth / 4
+		if bsz < 1 {
+			bsz = 1
+		}
+		h.undo = stack.NewWithOptions[entry[C]](stack.Options{
+			InitialBucketSize: bsz,
+			MaxBucketSize:     bsz,
+			GrowthFactor:      1,
+		})
+	} else {
+		h.undo = &stack.Stack[entry[C]]{}
+	}
+	return &h
+}
+
+// Do applies the command and records it in the history. The redo stack is cleared.
+func (h *History[C]) Do(cmd C) {
+	h.apply(cmd)
+	h.Record(cmd)
+}
+
+// Record records the command which has already been applied. The redo stack is cleared.
+func (h *History[C]) Record(cmd C) {
+	group := h.open
+	if h.depth == 0 {
+		h.group++
+		group = h.group
+	}
+	h.undo.Push(entry[C]{group: group, cmd: cmd})
+	h.redo.Clear()
+	h.evict()
+}
+
+// Begin starts a transaction: all the commands done until the matching Commit are undone and redone together.
+// Transactions can be nested, the nested ones are joined to the outer transaction.
+func (h *History[C]) Begin() {
+	if h.depth == 0 {
+		h.group++
+		h.open = h.group
+	}
+	h.depth++
+}
+
+// Commit finishes the transaction started with Begin.
+func (h *History[C]) Commit() {
+	if h.depth == 0 {
+		return
+	}
+	if h.depth--; h.depth == 0 {
+		h.open = 0
+	}
+}
+
+// Rollback reverts all the commands of the open transaction, including the nested ones, and forgets them.
+func (h *History[C]) Rollback() {
+	if h.depth == 0 {
+		return
+	}
+	for h.undo.Len() > 0 && h.undo.Peek().group == h.open {
+		h.revert(h.undo.Pop().cmd)
+	}
+	h.depth = 0
+	h.open = 0
+}
+
+// CanUndo returns true if there is something to undo.
+func (h *History[C]) CanUndo() bool {
+	return h.depth == 0 && h.undo.Len() > 0
+}
+
+// CanRedo returns true if there is something to redo.
+func (h *History[C]) CanRedo() bool {
+	return h.depth == 0 && h.redo.Len() > 0
+}
+
+// Undo reverts the last command or transaction and returns true if there was something to undo.
+// It does nothing while a transaction is open.
+func (h *History[C]) Undo() bool {
+	if !h.CanUndo() {
+		return false
+	}
+	move(h.undo, h.redo, h.revert)
+	return true
+}
+
+// Redo applies again the last undone command or transaction and returns true if there was something to redo.
+// It does nothing while a transaction is open.
+func (h *History[C]) Redo() bool {
+	if !h.CanRedo() {
+		return false
+	}
+	move(h.redo, h.undo, h.apply)
+	return true
+}
+
+// move pops the top group of entries from one stack, calls `fn` for each of them and pushes them to another stack.
+func move[C any](from, to *stack.Stack[entry[C]], fn func(C)) {
+	group := from.Peek().group
+	for from.Len() > 0 && from.Peek().group == group {
+		e := from.Pop()
+		fn(e.cmd)
+		to.Push(e)
+	}
+}
+
+// Len returns the count of commands that can be undone.
+func (h *History[C]) Len() int {
+	return h.undo.Len()
+}
+
+// Savepoint returns the mark of the current state, e.g. the state of a saved document.
+func (h *History[C]) Savepoint() Savepoint {
+	if h.undo.Len() == 0 {
+		return 0
+	}
+	return Savepoint(h.undo.Peek().group)
+}
+
+// AtSavepoint returns true if the history is in the state marked by the savepoint.
+func (h *History[C]) AtSavepoint(sp Savepoint) bool {
+	return h.Savepoint() == sp
+}
+
+// UndoTo undoes the commands until the history returns to the state marked by the savepoint.
+// It returns false without undoing anything if the state can not be reached, e.g. it was evicted or discarded
+// by a new command, or if a transaction is open.
+func (h *History[C]) UndoTo(sp Savepoint) bool {
+	if h.AtSavepoint(sp) {
+		return true
+	}
+	if h.depth > 0 || !h.reachable(sp) {
+		return false
+	}
+	for Savepoint(h.undoTop()) > sp {
+		h.Undo()
+	}
+	return true
+}
+
+// reachable returns true if the savepoint is the state after one of the groups in the undo stack,
+// or the initial state which was not evicted. The group IDs grow from the bottom to the top of the undo stack.
+func (h *History[C]) reachable(sp Savepoint) bool {
+	if sp == 0 {
+		return !h.evicted
+	}
+	n := h.undo.Len()
+	i := sort.Search(n, func(i int) bool {
+		return Savepoint(h.undo.Get(i).group) >= sp
+	})
+	return i < n && Savepoint(h.undo.Get(i).group) == sp
+}
+
+func (h *History[C]) undoTop() uint64 {
+	if h.undo.Len() == 0 {
+		return 0
+	}
+	return h.undo.Peek().group
+}
+
+// evict drops the oldest commands when the history is too deep.
+func (h *History[C]) evict() {
+	for h.maxDepth > 0 && h.undo.Len() > h.maxDepth {
+		n := h.undo.BottomBucketLen()
+		group := h.undo.Get(n - 1).group
+		if group == h.open {
+			// never evict the open transaction
+			return
+		}
+		h.undo.DropBottom(n)
+		h.evicted = true
+		for h.undo.Len() > 0 && h.undo.Get(0).group == group {
+			h.undo.DropBottom(1)
+		}
+	}
+}
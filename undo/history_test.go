@@ -0,0 +1,153 @@
+package undo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type counter struct {
+	val int
+}
+
+func newCounterHistory(maxDepth int) (*counter, *History[int]) {
+	c := &counter{}
+	return c, New[int](maxDepth, func(d int) {
+		c.val += d
+	}, func(d int) {
+		c.val -= d
+	})
+}
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+	t.Run("undo_redo", func(t *testing.T) {
+		t.Parallel()
+
+		c, h := newCounterHistory(0)
+		require.False(t, h.Undo())
+		h.Do(1)
+		h.Do(10)
+		h.Do(100)
+		require.Equal(t, 111, c.val)
+
+		require.True(t, h.Undo())
+		require.Equal(t, 11, c.val)
+		require.True(t, h.Undo())
+		require.Equal(t, 1, c.val)
+		require.True(t, h.Redo())
+		require.Equal(t, 11, c.val)
+
+		// a new command discards the redo stack
+		h.Do(1000)
+		require.False(t, h.CanRedo())
+		require.Equal(t, 1011, c.val)
+		require.Equal(t, 3, h.Len())
+	})
+	t.Run("transactions", func(t *testing.T) {
+		t.Parallel()
+
+		c, h := newCounterHistory(0)
+		h.Do(1)
+		h.Begin()
+		h.Do(10)
+		h.Begin()
+		h.Do(100)
+		h.Commit()
+		require.False(t, h.Undo())
+		h.Do(1000)
+		h.Commit()
+		require.Equal(t, 1111, c.val)
+
+		require.True(t, h.Undo())
+		require.Equal(t, 1, c.val)
+		require.True(t, h.Redo())
+		require.Equal(t, 1111, c.val)
+
+		h.Begin()
+		h.Do(5)
+		h.Do(5)
+		h.Rollback()
+		require.Equal(t, 1111, c.val)
+		require.True(t, h.Undo())
+		require.Equal(t, 1, c.val)
+	})
+	t.Run("savepoints", func(t *testing.T) {
+		t.Parallel()
+
+		c, h := newCounterHistory(0)
+		require.True(t, h.AtSavepoint(h.Savepoint()))
+		h.Do(1)
+		saved := h.Savepoint()
+		h.Do(2)
+		h.Do(3)
+		require.False(t, h.AtSavepoint(saved))
+		require.True(t, h.UndoTo(saved))
+		require.Equal(t, 1, c.val)
+		require.True(t, h.AtSavepoint(saved))
+		h.Redo()
+		h.Undo()
+		require.True(t, h.AtSavepoint(saved))
+
+		h.Undo()
+		h.Do(5)
+		// the saved state was discarded by the new command, nothing is undone
+		require.False(t, h.UndoTo(saved))
+		require.Equal(t, 5, c.val)
+		require.True(t, h.CanUndo())
+
+		require.True(t, h.UndoTo(0))
+		require.Equal(t, 0, c.val)
+	})
+	t.Run("savepoint_evicted", func(t *testing.T) {
+		t.Parallel()
+
+		c, h := newCounterHistory(4)
+		h.Do(1)
+		saved := h.Savepoint()
+		for n := 0; n < 10; n++ {
+			h.Do(1)
+		}
+		require.False(t, h.UndoTo(saved))
+		require.False(t, h.UndoTo(0))
+		require.Equal(t, 11, c.val)
+
+		h.Begin()
+		h.Do(1)
+		top := h.Savepoint()
+		require.False(t, h.UndoTo(top-1))
+		h.Commit()
+		require.True(t, h.UndoTo(top-1))
+		require.Equal(t, 11, c.val)
+	})
+	t.Run("max_depth", func(t *testing.T) {
+		t.Parallel()
+
+		c, h := newCounterHistory(100)
+		for n := 0; n < 1000; n++ {
+			h.Do(1)
+			require.LessOrEqual(t, h.Len(), 100)
+		}
+		require.GreaterOrEqual(t, h.Len(), 75)
+		depth := h.Len()
+		for h.Undo() {
+		}
+		require.Equal(t, 1000-depth, c.val)
+	})
+	t.Run("max_depth_transactions", func(t *testing.T) {
+		t.Parallel()
+
+		c, h := newCounterHistory(8)
+		for n := 0; n < 20; n++ {
+			h.Begin()
+			h.Do(1)
+			h.Do(1)
+			h.Do(1)
+			h.Commit()
+			require.Equal(t, 0, h.Len()%3, "a transaction was split")
+		}
+		for h.Undo() {
+		}
+		require.Equal(t, 0, c.val%3)
+	})
+}